@@ -1,24 +1,38 @@
 package main
 
 import (
+	"consumer/internal/blob"
 	"consumer/internal/config"
 	bookgrpc "consumer/internal/grpc"
+	"consumer/internal/health"
+	"consumer/internal/metrics"
 	"consumer/internal/queue"
 	"consumer/internal/service/analytics"
+	"consumer/internal/service/catalog"
 	"consumer/internal/service/processor"
+	"consumer/internal/storage"
 	"consumer/internal/storage/postgresql"
 	"context"
+	"errors"
 	"fmt"
 	confluentkafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/s-khechnev/pet-project/protos/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
 	"io"
 	"log"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func initLog(env string) *slog.Logger {
@@ -61,15 +75,63 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	bookRepo, err := postgresql.NewStorage(ctx, cfg.DB.ConnString)
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, "consumer")
+	if err != nil {
+		log.Fatalf("failed to init tracing: %v", err)
+	}
+
+	bookRepo, err := postgresql.NewStorage(ctx, cfg.DB.ConnString, cfg.Search.Language)
 	if err != nil {
 		log.Fatalf("failed to connect to postgres: %v", err)
 	}
-	bookProcessorService := processor.NewBookProcessorService(bookRepo)
+
+	statusProducer, err := queue.NewStatusProducer(
+		cfg.Kafka.StatusTopic,
+		&confluentkafka.ConfigMap{
+			"bootstrap.servers": cfg.Kafka.BootstrapServers,
+		},
+		cfg.Kafka.FlushTimeout,
+	)
+	if err != nil {
+		log.Fatalf("failed create kafka status producer: %v", err)
+	}
+
+	bookProcessorService := processor.NewBookProcessorService(bookRepo, statusProducer)
+
+	redeliveryProducer, err := queue.NewRedeliveryProducer(
+		&confluentkafka.ConfigMap{
+			"bootstrap.servers": cfg.Kafka.BootstrapServers,
+			"acks":              "all",
+		},
+		cfg.Kafka.FlushTimeout,
+	)
+	if err != nil {
+		log.Fatalf("failed create kafka redelivery producer: %v", err)
+	}
+	if cfg.Kafka.DeadLetterTopic == "" {
+		logger.Warn("dead-letter topic is not configured")
+	}
+
+	retryPolicy := queue.RetryPolicy{
+		MaxAttempts:    cfg.Retry.MaxAttempts,
+		InitialBackoff: cfg.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Retry.MaxBackoff,
+		Multiplier:     cfg.Retry.Multiplier,
+		Jitter:         cfg.Retry.Jitter,
+		Retryable: func(err error) bool {
+			return storage.IsRetryable(err) || blob.IsRetryable(err)
+		},
+	}
+	for _, t := range cfg.Retry.Topics {
+		retryPolicy.Topics = append(retryPolicy.Topics, queue.RetryTopic{Topic: t.Topic, Delay: t.Delay})
+	}
 
 	kafkaConsumer, err := queue.NewConsumer(
 		ctx,
 		bookProcessorService,
+		blob.NewFSStore(cfg.Blob.Dir),
+		redeliveryProducer,
+		statusProducer,
 		cfg.Kafka.MessageTopic,
 		cfg.Kafka.GroupId,
 		&confluentkafka.ConfigMap{
@@ -78,6 +140,19 @@ func main() {
 			"auto.offset.reset":  cfg.Kafka.AutoOffsetReset,
 		},
 		cfg.Kafka.PollTimeout,
+		cfg.Kafka.DeadLetterTopic,
+		retryPolicy,
+		queue.CommitPolicy{
+			Interval: cfg.Kafka.CommitInterval,
+			Batch:    cfg.Kafka.CommitBatch,
+		},
+		queue.WorkerPolicy{
+			Concurrency: cfg.Workers.Concurrency,
+			KeyWorkers:  cfg.Workers.KeyWorkers,
+			QueueSize:   cfg.Workers.QueueSize,
+			BatchSize:   cfg.Workers.BatchSize,
+			BatchLinger: cfg.Workers.BatchLinger,
+		},
 	)
 	if err != nil {
 		log.Fatalf("failed create kafka consumer: %v", err)
@@ -90,10 +165,29 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		// health probes run every few seconds and would bury the real traces
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.UnaryInterceptor(metrics.UnaryServerInterceptor()),
+	)
 	analyticsService := analytics.NewBookAnalyticsService(bookRepo)
 	serverApi := bookgrpc.NewServerApi(analyticsService)
 	bookgrpc.Register(grpcServer, serverApi)
+	catalogService := catalog.NewBookCatalogService(bookRepo)
+	bookgrpc.RegisterBooks(grpcServer, bookgrpc.NewBooksApi(catalogService))
+	authorService := catalog.NewAuthorCatalogService(bookRepo)
+	bookgrpc.RegisterAuthors(grpcServer, bookgrpc.NewAuthorsApi(authorService))
+
+	healthServer := grpchealth.NewServer()
+	healthv1.RegisterHealthServer(grpcServer, healthServer)
+
+	healthMonitor := health.NewMonitor(healthServer, cfg.Health.Interval, cfg.Health.Timeout)
+	healthMonitor.Add("postgres", bookRepo.Ping)
+	healthMonitor.Add("kafka", kafkaConsumer.Subscribed)
+	healthMonitor.Add("consumer", func(context.Context) error {
+		return kafkaConsumer.Alive(cfg.Health.MaxPollDelay)
+	})
+	go healthMonitor.Run(ctx)
 
 	go func() {
 		if err := grpcServer.Serve(l); err != nil {
@@ -101,13 +195,45 @@ func main() {
 		}
 	}()
 
+	metrics.RegisterPool(bookRepo.Stat)
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+	metricsServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Metrics.Port),
+		Handler: metricsMux,
+	}
+
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed to serve metrics", slog.String("error", err.Error()))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("shutdown Server")
 
+	healthServer.Shutdown()
 	grpcServer.GracefulStop()
+	if err := metricsServer.Close(); err != nil {
+		logger.Error("failed close metrics server", slog.String("error", err.Error()))
+	}
+	// the consumer still publishes through the producers until it stops
+	cancel()
+	if err := kafkaConsumer.Close(); err != nil {
+		logger.Error("failed close kafka consumer", slog.String("error", err.Error()))
+	}
+	statusProducer.Close()
+	redeliveryProducer.Close()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed shutdown tracing", slog.String("error", err.Error()))
+	}
 
 	logger.Info("server exiting")
 }
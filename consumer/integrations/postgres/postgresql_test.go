@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"consumer/internal/entity"
+	"consumer/internal/service/catalog"
 	"consumer/internal/service/processor"
 	storagePkg "consumer/internal/storage"
 	"consumer/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/pressly/goose/v3"
@@ -20,6 +22,12 @@ import (
 	_ "github.com/lib/pq"
 )
 
+type nopStatusPublisher struct{}
+
+func (nopStatusPublisher) Publish(entity.BookStatus) error {
+	return nil
+}
+
 func Test(t *testing.T) {
 	ctx := context.Background()
 
@@ -64,12 +72,12 @@ func Test(t *testing.T) {
 		log.Fatalf("failed to close connection: %s", err)
 	}
 
-	storage, err := postgresql.NewStorage(ctx, connStr)
+	storage, err := postgresql.NewStorage(ctx, connStr, "english")
 	if err != nil {
 		log.Fatalf("failed to create storage: %s", err)
 	}
 
-	processor := processor.NewBookProcessorService(storage)
+	processor := processor.NewBookProcessorService(storage, nopStatusPublisher{})
 
 	books := []entity.Book{
 		{
@@ -91,7 +99,7 @@ func Test(t *testing.T) {
 	slices.SortFunc(books, cmpBook)
 
 	for _, book := range books {
-		if err := processor.Process(ctx, book); err != nil {
+		if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventCreated, Book: book}); err != nil {
 			log.Fatalf("failed to process book: %s", err)
 		}
 	}
@@ -101,7 +109,7 @@ func Test(t *testing.T) {
 		log.Fatalf("failed to connect to postgres for test processors: %s", err)
 	}
 
-	rows, err := conn.Query(ctx, "SELECT * FROM books")
+	rows, err := conn.Query(ctx, "SELECT id, title, text, version FROM books")
 	if err != nil {
 		log.Fatalf("failed to query books: %s", err)
 	}
@@ -109,7 +117,7 @@ func Test(t *testing.T) {
 	actualBooks := make([]entity.Book, 0)
 	for rows.Next() {
 		var actualBook storagePkg.BookRow
-		if err := rows.Scan(&actualBook.Id, &actualBook.Title, &actualBook.Text); err != nil {
+		if err := rows.Scan(&actualBook.Id, &actualBook.Title, &actualBook.Text, &actualBook.Version); err != nil {
 			log.Fatalf("failed to scan actualBook: %s", err)
 		}
 		actualBooks = append(actualBooks, storagePkg.ToModel(actualBook))
@@ -133,4 +141,291 @@ func Test(t *testing.T) {
 			log.Fatalf("wrong processing: expected %s got %s", actualBooks[i].Text, books[i].Text)
 		}
 	}
+
+	events := []entity.BookEvent{
+		{
+			Type:   entity.EventUpdated,
+			Book:   entity.Book{Id: books[0].Id, Title: "new title"},
+			Fields: []string{entity.FieldTitle},
+		},
+		{
+			Type: entity.EventUpdated,
+			Book: entity.Book{Id: books[1].Id, Title: books[1].Title, Authors: []string{"Author1"}, Text: books[1].Text},
+		},
+	}
+	for _, event := range events {
+		if err := processor.Process(ctx, event); err != nil {
+			log.Fatalf("failed to update book: %s", err)
+		}
+	}
+
+	var title string
+	if err := conn.QueryRow(ctx, "SELECT title FROM books WHERE id = $1", books[0].Id).Scan(&title); err != nil {
+		log.Fatalf("failed to query title: %s", err)
+	}
+	if title != "new title" {
+		log.Fatalf("expected patched title, got %s", title)
+	}
+
+	if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventDeleted, Book: entity.Book{Id: books[0].Id}}); err != nil {
+		log.Fatalf("failed to delete book: %s", err)
+	}
+
+	countBooks, err := storage.GetCountBooks(ctx)
+	if err != nil {
+		log.Fatalf("failed to count books: %s", err)
+	}
+	if countBooks != 1 {
+		log.Fatalf("expected 1 book after delete, got %d", countBooks)
+	}
+
+	// "single author" and "Author2" lost their only books
+	countAuthors, err := storage.GetCountAuthors(ctx)
+	if err != nil {
+		log.Fatalf("failed to count authors: %s", err)
+	}
+	if countAuthors != 1 {
+		log.Fatalf("expected 1 author after cleanup, got %d", countAuthors)
+	}
+
+	// an update may come before the create of its book, which is still retried
+	early := entity.BookEvent{
+		Type:   entity.EventUpdated,
+		Book:   entity.Book{Id: uuid.New().String(), Title: "early title"},
+		Fields: []string{entity.FieldTitle},
+	}
+	err = processor.Process(ctx, early)
+	if !errors.Is(err, storagePkg.ErrBookNotFound) || !storagePkg.IsRetryable(err) {
+		log.Fatalf("expected retryable not found for missing book, got %v", err)
+	}
+
+	created := entity.BookEvent{
+		Type: entity.EventCreated,
+		Book: entity.Book{Id: early.Book.Id, Title: "created", Authors: []string{"Author1"}, Text: "text"},
+	}
+	for _, event := range []entity.BookEvent{created, early} {
+		if err := processor.Process(ctx, event); err != nil {
+			log.Fatalf("failed to process %s book event: %s", event.Type, err)
+		}
+	}
+	if err := conn.QueryRow(ctx, "SELECT title FROM books WHERE id = $1", early.Book.Id).Scan(&title); err != nil {
+		log.Fatalf("failed to query title: %s", err)
+	}
+	if title != "early title" {
+		log.Fatalf("expected the retried update to apply, got %s", title)
+	}
+	if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventDeleted, Book: entity.Book{Id: early.Book.Id}}); err != nil {
+		log.Fatalf("failed to delete book: %s", err)
+	}
+
+	batch := []entity.Book{
+		{Id: uuid.New().String(), Title: "batch 1", Authors: []string{"Author1", "Batch author"}, Text: "batch text 1"},
+		{Id: uuid.New().String(), Title: "batch 2", Authors: []string{"Batch author", " Batch author "}, Text: "batch text 2"},
+	}
+	if err := processor.ProcessBatch(ctx, batch); err != nil {
+		log.Fatalf("failed to process batch: %s", err)
+	}
+
+	countBooks, err = storage.GetCountBooks(ctx)
+	if err != nil {
+		log.Fatalf("failed to count books: %s", err)
+	}
+	if countBooks != 3 {
+		log.Fatalf("expected 3 books after batch, got %d", countBooks)
+	}
+
+	countAuthors, err = storage.GetCountAuthors(ctx)
+	if err != nil {
+		log.Fatalf("failed to count authors: %s", err)
+	}
+	if countAuthors != 2 {
+		log.Fatalf("expected 2 authors after batch, got %d", countAuthors)
+	}
+
+	var text string
+	if err := conn.QueryRow(ctx, "SELECT text FROM books WHERE id = $1", batch[1].Id).Scan(&text); err != nil {
+		log.Fatalf("failed to query text: %s", err)
+	}
+	if text != strings.ToUpper(batch[1].Text) {
+		log.Fatalf("wrong batch processing: expected %s got %s", strings.ToUpper(batch[1].Text), text)
+	}
+
+	redelivered := entity.Book{
+		Id:      uuid.New().String(),
+		Title:   "versioned",
+		Authors: []string{"Redelivered author"},
+		Text:    "first",
+		Version: 100,
+	}
+	for i := 0; i < 2; i++ {
+		if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventCreated, Book: redelivered}); err != nil {
+			log.Fatalf("failed to process redelivered book: %s", err)
+		}
+	}
+
+	stale := redelivered
+	stale.Text, stale.Version = "stale", 50
+	newer := redelivered
+	newer.Text, newer.Version = "newer", 200
+	for _, book := range []entity.Book{newer, stale} {
+		if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventCreated, Book: book}); err != nil {
+			log.Fatalf("failed to process book version %d: %s", book.Version, err)
+		}
+	}
+
+	var version int64
+	if err := conn.QueryRow(ctx, "SELECT text, version FROM books WHERE id = $1", redelivered.Id).Scan(&text, &version); err != nil {
+		log.Fatalf("failed to query versioned book: %s", err)
+	}
+	if text != "NEWER" || version != 200 {
+		log.Fatalf("expected the newer version to win, got %s at %d", text, version)
+	}
+
+	var links int64
+	if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM book_authors WHERE book_id = $1", redelivered.Id).Scan(&links); err != nil {
+		log.Fatalf("failed to query versioned book authors: %s", err)
+	}
+	if links != 1 {
+		log.Fatalf("expected 1 author of the versioned book, got %d", links)
+	}
+
+	catalogService := catalog.NewBookCatalogService(storage)
+
+	stored, err := catalogService.GetBook(ctx, redelivered.Id)
+	if err != nil {
+		log.Fatalf("failed to get book: %s", err)
+	}
+	if stored.Text != "NEWER" || !slices.Equal(stored.Authors, redelivered.Authors) {
+		log.Fatalf("unexpected stored book: %+v", stored)
+	}
+
+	if _, err := catalogService.GetBook(ctx, uuid.New().String()); !errors.Is(err, storagePkg.ErrBookNotFound) {
+		log.Fatalf("expected not found for missing book, got %v", err)
+	}
+
+	var (
+		titles []string
+		token  string
+	)
+	for {
+		page, err := catalogService.ListBooks(ctx, catalog.ListBooksParams{
+			PageSize:  1,
+			PageToken: token,
+			OrderBy:   storagePkg.OrderByTitle,
+			Title:     "BATCH",
+		})
+		if err != nil {
+			log.Fatalf("failed to list books: %s", err)
+		}
+		for _, book := range page.Books {
+			titles = append(titles, book.Title)
+		}
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+	if !slices.Equal(titles, []string{"batch 1", "batch 2"}) {
+		log.Fatalf("expected batch books by title, got %v", titles)
+	}
+
+	page, err := catalogService.ListBooks(ctx, catalog.ListBooksParams{Author: "Author1", Descending: true})
+	if err != nil {
+		log.Fatalf("failed to list books of author: %s", err)
+	}
+	if len(page.Books) != 2 || page.NextPageToken != "" {
+		log.Fatalf("expected 2 books of Author1, got %+v", page)
+	}
+
+	authorService := catalog.NewAuthorCatalogService(storage)
+
+	authorPage, err := authorService.ListAuthors(ctx, catalog.ListAuthorsParams{Prefix: "batch"})
+	if err != nil {
+		log.Fatalf("failed to list authors: %s", err)
+	}
+	if len(authorPage.Authors) != 1 || authorPage.Authors[0].Name != "Batch author" {
+		log.Fatalf("expected Batch author by prefix, got %+v", authorPage.Authors)
+	}
+
+	stats, err := authorService.GetAuthor(ctx, authorPage.Authors[0].Id)
+	if err != nil {
+		log.Fatalf("failed to get author: %s", err)
+	}
+	if stats.CountBooks != 2 || stats.CountTextSymbols != int64(len(batch[0].Text)+len(batch[1].Text)) {
+		log.Fatalf("unexpected author stats: %+v", stats)
+	}
+
+	page, err = authorService.ListAuthorBooks(ctx, stats.Author.Id, catalog.ListBooksParams{OrderBy: storagePkg.OrderByTitle})
+	if err != nil {
+		log.Fatalf("failed to list books of author: %s", err)
+	}
+	if len(page.Books) != 2 || page.Books[0].Title != "batch 1" {
+		log.Fatalf("expected batch books of Batch author, got %+v", page.Books)
+	}
+
+	if _, err := authorService.GetAuthor(ctx, stats.Author.Id+1000); !errors.Is(err, storagePkg.ErrAuthorNotFound) {
+		log.Fatalf("expected not found for missing author, got %v", err)
+	}
+
+	searchPage, err := catalogService.SearchBooks(ctx, catalog.SearchBooksParams{Query: `"batch text" -2`})
+	if err != nil {
+		log.Fatalf("failed to search books: %s", err)
+	}
+	if len(searchPage.Matches) != 1 || searchPage.Matches[0].Book.Id != batch[0].Id {
+		log.Fatalf("expected only the first batch book, got %+v", searchPage.Matches)
+	}
+	if !strings.Contains(searchPage.Matches[0].Snippet, "<b>BATCH</b>") {
+		log.Fatalf("expected highlighted snippet, got %s", searchPage.Matches[0].Snippet)
+	}
+
+	var found []string
+	token = ""
+	for {
+		searchPage, err := catalogService.SearchBooks(ctx, catalog.SearchBooksParams{Query: "batch", PageSize: 1, PageToken: token})
+		if err != nil {
+			log.Fatalf("failed to search books: %s", err)
+		}
+		for _, match := range searchPage.Matches {
+			found = append(found, match.Book.Id)
+		}
+		if token = searchPage.NextPageToken; token == "" {
+			break
+		}
+	}
+	if len(found) != 2 || found[0] == found[1] {
+		log.Fatalf("expected both batch books over two pages, got %v", found)
+	}
+
+	_, err = catalogService.SearchBooks(ctx, catalog.SearchBooksParams{Query: "batch", Language: "klingon"})
+	if !errors.Is(err, catalog.ErrInvalidArgument) {
+		log.Fatalf("expected invalid argument for unknown language, got %v", err)
+	}
+
+	deleted := redelivered
+	deleted.Version = 300
+	if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventDeleted, Book: deleted}); err != nil {
+		log.Fatalf("failed to delete versioned book: %s", err)
+	}
+
+	late := redelivered
+	late.Text, late.Version = "late", 250
+	if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventCreated, Book: late}); err != nil {
+		log.Fatalf("failed to process late book: %s", err)
+	}
+	err = processor.Process(ctx, entity.BookEvent{Type: entity.EventUpdated, Book: late, Fields: []string{entity.FieldText}})
+	if err != nil {
+		log.Fatalf("failed to process late update: %s", err)
+	}
+	if _, err := catalogService.GetBook(ctx, redelivered.Id); !errors.Is(err, storagePkg.ErrBookNotFound) {
+		log.Fatalf("expected the deleted book to stay deleted, got %v", err)
+	}
+
+	recreated := redelivered
+	recreated.Text, recreated.Version = "recreated", 400
+	if err := processor.Process(ctx, entity.BookEvent{Type: entity.EventCreated, Book: recreated}); err != nil {
+		log.Fatalf("failed to recreate book: %s", err)
+	}
+	stored, err = catalogService.GetBook(ctx, redelivered.Id)
+	if err != nil || stored.Text != "RECREATED" {
+		log.Fatalf("expected the recreated book, got %+v, %v", stored, err)
+	}
 }
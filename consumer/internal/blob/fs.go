@@ -0,0 +1,54 @@
+package blob
+
+import (
+	"consumer/internal/entity"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidKey   = errors.New("invalid blob key")
+	ErrHashMismatch = errors.New("blob hash mismatch")
+	// ErrUnavailable is returned when the blob cannot be read, the shared
+	// store may be back or catch up later.
+	ErrUnavailable = errors.New("blob is unavailable")
+)
+
+// FSStore reads texts written to the shared content-addressed blob store by
+// the producer.
+type FSStore struct {
+	dir string
+}
+
+func NewFSStore(dir string) *FSStore {
+	return &FSStore{dir: dir}
+}
+
+func (s *FSStore) Get(ref entity.TextRef) ([]byte, error) {
+	key := filepath.FromSlash(ref.Key)
+	if !filepath.IsLocal(key) || !strings.HasSuffix(ref.Key, ref.Sha256) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, ref.Key)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read blob %s: %w", ErrUnavailable, ref.Key, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != ref.Sha256 || int64(len(data)) != ref.Size {
+		return nil, fmt.Errorf("%w: %s", ErrHashMismatch, ref.Key)
+	}
+
+	return data, nil
+}
+
+// IsRetryable reports whether reading the blob again may succeed.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
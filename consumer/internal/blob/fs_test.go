@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"consumer/internal/entity"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFSStore(dir)
+
+	data := []byte("War and Peace")
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := hash[:2] + "/" + hash
+
+	// the layout the producer writes blobs in
+	path := filepath.Join(dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %s", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write blob: %s", err)
+	}
+
+	tests := []struct {
+		name            string
+		ref             entity.TextRef
+		expectErr       error
+		expectRetryable bool
+	}{
+		{
+			name: "stored",
+			ref:  entity.TextRef{Key: key, Sha256: hash, Size: int64(len(data))},
+		},
+		{
+			name:      "key outside the store",
+			ref:       entity.TextRef{Key: "../" + hash, Sha256: hash, Size: int64(len(data))},
+			expectErr: ErrInvalidKey,
+		},
+		{
+			name:      "key of another hash",
+			ref:       entity.TextRef{Key: key, Sha256: "abcdef", Size: int64(len(data))},
+			expectErr: ErrInvalidKey,
+		},
+		{
+			name:      "size mismatch",
+			ref:       entity.TextRef{Key: key, Sha256: hash, Size: 3},
+			expectErr: ErrHashMismatch,
+		},
+		{
+			name:            "missing",
+			ref:             entity.TextRef{Key: "ab/ab", Sha256: "ab", Size: 3},
+			expectErr:       ErrUnavailable,
+			expectRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Get(tt.ref)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expect error %v, but got %v", tt.expectErr, err)
+			}
+			if retryable := IsRetryable(err); retryable != tt.expectRetryable {
+				t.Errorf("expect retryable %t, but got %t", tt.expectRetryable, retryable)
+			}
+			if tt.expectErr == nil && string(got) != string(data) {
+				t.Errorf("expect blob %q, but got %q", data, got)
+			}
+		})
+	}
+
+	// a blob changed on disk does not match its hash any more
+	if err := os.WriteFile(path, []byte("Anna Karenina"), 0644); err != nil {
+		t.Fatalf("failed to overwrite blob: %s", err)
+	}
+	_, err := store.Get(entity.TextRef{Key: key, Sha256: hash, Size: int64(len(data))})
+	if !errors.Is(err, ErrHashMismatch) || IsRetryable(err) {
+		t.Errorf("expect permanent hash mismatch, but got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"github.com/s-khechnev/pet-project/protos/tracing"
 	"log"
 	"os"
 	"time"
@@ -13,6 +14,13 @@ type Config struct {
 	Env        string     `yaml:"env"`
 	GrpcServer GrpcServer `yaml:"grpc"`
 	Kafka      Kafka      `yaml:"kafka"`
+	Blob       Blob       `yaml:"blob"`
+	Health     Health     `yaml:"health"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Retry      Retry      `yaml:"retry"`
+	Workers    Workers    `yaml:"workers"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Search     Search     `yaml:"search"`
 	DB         DB
 }
 
@@ -24,10 +32,68 @@ type GrpcServer struct {
 type Kafka struct {
 	BootstrapServers string        `yaml:"bootstrap_servers"`
 	MessageTopic     string        `yaml:"message_topic"`
+	StatusTopic      string        `yaml:"status_topic"`
+	DeadLetterTopic  string        `yaml:"dead_letter_topic"`
 	GroupId          string        `yaml:"group_id"`
 	PollTimeout      time.Duration `yaml:"poll_timeout"`
 	SessionTimeout   time.Duration `yaml:"session_timeout"`
 	AutoOffsetReset  string        `yaml:"auto_offset_reset"`
+	FlushTimeout     int           `yaml:"flush_timeout"`
+	CommitInterval   time.Duration `yaml:"commit_interval"`
+	CommitBatch      int           `yaml:"commit_batch"`
+}
+
+// Workers process every assigned partition on KeyWorkers goroutines, books
+// are spread over them by id. A partition is paused while any of its worker
+// queues is full. Concurrency bounds the events processed at once. New books
+// are saved in batches of up to BatchSize, waiting at most BatchLinger.
+type Workers struct {
+	Concurrency int           `yaml:"concurrency"`
+	KeyWorkers  int           `yaml:"key_workers"`
+	QueueSize   int           `yaml:"queue_size"`
+	BatchSize   int           `yaml:"batch_size"`
+	BatchLinger time.Duration `yaml:"batch_linger"`
+}
+
+// Retry of transient failures: MaxAttempts in process with exponential backoff,
+// then through the delayed retry Topics in order, then the dead-letter topic.
+type Retry struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Multiplier     float64       `yaml:"multiplier"`
+	Jitter         float64       `yaml:"jitter"`
+	Topics         []RetryTopic  `yaml:"topics"`
+}
+
+type RetryTopic struct {
+	Topic string        `yaml:"topic"`
+	Delay time.Duration `yaml:"delay"`
+}
+
+// Health checks run every Interval. MaxPollDelay is how long the poll loop
+// may be busy with a single book before the consumer is reported dead.
+type Health struct {
+	Interval     time.Duration `yaml:"interval"`
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxPollDelay time.Duration `yaml:"max_poll_delay"`
+}
+
+type Metrics struct {
+	Port int `yaml:"port"`
+}
+
+// Tracing is shared by the services.
+type Tracing = tracing.Config
+
+// Search language is the Postgres text search configuration new books are
+// indexed with, such as english, russian or simple.
+type Search struct {
+	Language string `yaml:"language"`
+}
+
+type Blob struct {
+	Dir string `yaml:"dir"`
 }
 
 type DB struct {
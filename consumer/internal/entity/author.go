@@ -0,0 +1,13 @@
+package entity
+
+type Author struct {
+	Id   int64
+	Name string
+}
+
+// AuthorStats sums up the books of an author.
+type AuthorStats struct {
+	Author           Author
+	CountBooks       int64
+	CountTextSymbols int64
+}
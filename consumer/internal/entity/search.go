@@ -0,0 +1,10 @@
+package entity
+
+// BookMatch is a book found by a text search. Title and Snippet mark the
+// matched words with <b></b>.
+type BookMatch struct {
+	Book    Book
+	Rank    float32
+	Title   string
+	Snippet string
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+type Status string
+
+const (
+	StatusProcessing Status = "processing"
+	StatusSaved      Status = "saved"
+	StatusDeleted    Status = "deleted"
+	StatusFailed     Status = "failed"
+)
+
+type BookStatus struct {
+	Id        string    `json:"id"`
+	Status    Status    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,94 @@
+package bookgrpc
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/service/catalog"
+	"context"
+	catalogv1 "github.com/s-khechnev/pet-project/protos/gen/go/catalog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AuthorsApi struct {
+	authorService *catalog.AuthorCatalogService
+	catalogv1.UnimplementedAuthorsServer
+}
+
+func NewAuthorsApi(authorService *catalog.AuthorCatalogService) *AuthorsApi {
+	return &AuthorsApi{
+		authorService: authorService,
+	}
+}
+
+func RegisterAuthors(server *grpc.Server, api *AuthorsApi) {
+	catalogv1.RegisterAuthorsServer(server, api)
+}
+
+func (s *AuthorsApi) ListAuthors(ctx context.Context, req *catalogv1.ListAuthorsRequest) (*catalogv1.ListAuthorsResponse, error) {
+	page, err := s.authorService.ListAuthors(ctx, catalog.ListAuthorsParams{
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+		Prefix:    req.GetPrefix(),
+	})
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	authors := make([]*catalogv1.Author, 0, len(page.Authors))
+	for _, author := range page.Authors {
+		authors = append(authors, toAuthor(author))
+	}
+
+	return &catalogv1.ListAuthorsResponse{
+		Authors:       authors,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func (s *AuthorsApi) GetAuthor(ctx context.Context, req *catalogv1.GetAuthorRequest) (*catalogv1.GetAuthorResponse, error) {
+	stats, err := s.authorService.GetAuthor(ctx, req.GetId())
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	return &catalogv1.GetAuthorResponse{
+		Author:           toAuthor(stats.Author),
+		CountBooks:       stats.CountBooks,
+		CountTextSymbols: stats.CountTextSymbols,
+	}, nil
+}
+
+func (s *AuthorsApi) ListAuthorBooks(ctx context.Context, req *catalogv1.ListAuthorBooksRequest) (*catalogv1.ListAuthorBooksResponse, error) {
+	orderBy, ok := bookOrders[req.GetOrderBy()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown order %s", req.GetOrderBy())
+	}
+
+	page, err := s.authorService.ListAuthorBooks(ctx, req.GetAuthorId(), catalog.ListBooksParams{
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+		OrderBy:    orderBy,
+		Descending: req.GetDescending(),
+	})
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	books := make([]*catalogv1.Book, 0, len(page.Books))
+	for _, book := range page.Books {
+		books = append(books, toBook(book))
+	}
+
+	return &catalogv1.ListAuthorBooksResponse{
+		Books:         books,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func toAuthor(author entity.Author) *catalogv1.Author {
+	return &catalogv1.Author{
+		Id:   author.Id,
+		Name: author.Name,
+	}
+}
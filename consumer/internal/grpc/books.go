@@ -0,0 +1,123 @@
+package bookgrpc
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/service/catalog"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	catalogv1 "github.com/s-khechnev/pet-project/protos/gen/go/catalog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type BooksApi struct {
+	catalogService *catalog.BookCatalogService
+	catalogv1.UnimplementedBooksServer
+}
+
+func NewBooksApi(catalogService *catalog.BookCatalogService) *BooksApi {
+	return &BooksApi{
+		catalogService: catalogService,
+	}
+}
+
+func RegisterBooks(server *grpc.Server, api *BooksApi) {
+	catalogv1.RegisterBooksServer(server, api)
+}
+
+func (s *BooksApi) GetBook(ctx context.Context, req *catalogv1.GetBookRequest) (*catalogv1.GetBookResponse, error) {
+	book, err := s.catalogService.GetBook(ctx, req.GetId())
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	return &catalogv1.GetBookResponse{Book: toBook(book)}, nil
+}
+
+var bookOrders = map[catalogv1.BookOrder]storage.BookOrder{
+	catalogv1.BookOrder_BOOK_ORDER_UNSPECIFIED: storage.OrderById,
+	catalogv1.BookOrder_BOOK_ORDER_ID:          storage.OrderById,
+	catalogv1.BookOrder_BOOK_ORDER_TITLE:       storage.OrderByTitle,
+}
+
+func (s *BooksApi) ListBooks(ctx context.Context, req *catalogv1.ListBooksRequest) (*catalogv1.ListBooksResponse, error) {
+	orderBy, ok := bookOrders[req.GetOrderBy()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown order %s", req.GetOrderBy())
+	}
+
+	page, err := s.catalogService.ListBooks(ctx, catalog.ListBooksParams{
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+		OrderBy:    orderBy,
+		Descending: req.GetDescending(),
+		Author:     req.GetAuthor(),
+		Title:      req.GetTitle(),
+	})
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	books := make([]*catalogv1.Book, 0, len(page.Books))
+	for _, book := range page.Books {
+		books = append(books, toBook(book))
+	}
+
+	return &catalogv1.ListBooksResponse{
+		Books:         books,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func (s *BooksApi) SearchBooks(ctx context.Context, req *catalogv1.SearchBooksRequest) (*catalogv1.SearchBooksResponse, error) {
+	page, err := s.catalogService.SearchBooks(ctx, catalog.SearchBooksParams{
+		Query:     req.GetQuery(),
+		Language:  req.GetLanguage(),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	matches := make([]*catalogv1.BookMatch, 0, len(page.Matches))
+	for _, match := range page.Matches {
+		matches = append(matches, &catalogv1.BookMatch{
+			Book:           toBook(match.Book),
+			Rank:           match.Rank,
+			TitleHighlight: match.Title,
+			Snippet:        match.Snippet,
+		})
+	}
+
+	return &catalogv1.SearchBooksResponse{
+		Matches:       matches,
+		NextPageToken: page.NextPageToken,
+	}, nil
+}
+
+func toBook(book entity.Book) *catalogv1.Book {
+	return &catalogv1.Book{
+		Id:      book.Id,
+		Title:   book.Title,
+		Authors: book.Authors,
+		Text:    book.Text,
+	}
+}
+
+func catalogError(err error) error {
+	switch {
+	case errors.Is(err, catalog.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrBookNotFound), errors.Is(err, storage.ErrAuthorNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"log/slog"
+	"time"
+)
+
+type Check func(ctx context.Context) error
+
+// Monitor runs the checks periodically and reports them through the standard
+// gRPC health service: every check as a service of its own name and the
+// overall status as the empty service name.
+type Monitor struct {
+	server   *health.Server
+	checks   map[string]Check
+	interval time.Duration
+	timeout  time.Duration
+}
+
+func NewMonitor(server *health.Server, interval time.Duration, timeout time.Duration) *Monitor {
+	return &Monitor{
+		server:   server,
+		checks:   make(map[string]Check),
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+func (m *Monitor) Add(name string, check Check) {
+	m.checks[name] = check
+}
+
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	overall := healthv1.HealthCheckResponse_SERVING
+	for name, check := range m.checks {
+		status := healthv1.HealthCheckResponse_SERVING
+		if err := check(ctx); err != nil {
+			slog.Warn("health check failed", slog.String("check", name), slog.String("error", err.Error()))
+			status = healthv1.HealthCheckResponse_NOT_SERVING
+			overall = status
+		}
+		m.server.SetServingStatus(name, status)
+	}
+	m.server.SetServingStatus("", overall)
+}
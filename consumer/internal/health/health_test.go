@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	server := health.NewServer()
+	monitor := NewMonitor(server, time.Second, time.Second)
+
+	var postgresErr error
+	monitor.Add("postgres", func(context.Context) error { return postgresErr })
+	monitor.Add("kafka", func(context.Context) error { return nil })
+
+	status := func(service string) healthv1.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthv1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("failed to check %q: %s", service, err)
+		}
+		return resp.GetStatus()
+	}
+
+	monitor.check(context.Background())
+	if status("") != healthv1.HealthCheckResponse_SERVING {
+		t.Errorf("expect serving when all checks pass")
+	}
+
+	postgresErr = errors.New("connection refused")
+	monitor.check(context.Background())
+	if status("") != healthv1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expect not serving when a check fails")
+	}
+	if status("postgres") != healthv1.HealthCheckResponse_NOT_SERVING || status("kafka") != healthv1.HealthCheckResponse_SERVING {
+		t.Errorf("expect every check to be reported separately")
+	}
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const namespace = "consumer"
+
+var (
+	ConsumedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumed_messages_total",
+		Help:      "Messages read from Kafka.",
+	}, []string{"topic"})
+
+	DecodeFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "decode_failures_total",
+		Help:      "Messages that could not be decoded into a book event.",
+	})
+
+	CommitFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "commit_failures_total",
+		Help:      "Failed offset commits.",
+	})
+
+	DeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "dead_letters_total",
+		Help:      "Messages moved to the dead-letter topic by the stage that failed.",
+	}, []string{"stage"})
+
+	Retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "retries_total",
+		Help:      "Retries of failed book events, in process with backoff or through a delayed retry topic.",
+	}, []string{"kind"})
+
+	PartitionLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "partition_lag",
+		Help:      "Messages between the consumer position and the high watermark of an assigned partition.",
+	}, []string{"topic", "partition"})
+
+	ProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "processor",
+		Name:      "duration_seconds",
+		Help:      "Time to process a book event by event type and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type", "result"})
+
+	BatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "processor",
+		Name:      "batch_size",
+		Help:      "Books saved by a single bulk insert.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	SaveFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "save_failures_total",
+		Help:      "Failures to apply a book event to the storage by event type and error class.",
+	}, []string{"type", "class"})
+
+	GrpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+
+	GrpcRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC request latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	RetryBackoff = "backoff"
+	RetryTopic   = "topic"
+)
+
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		GrpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		GrpcRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(namespace+"_pgxpool_acquired_conns",
+		"Connections currently acquired from the pool.", nil, nil)
+	poolIdleConns = prometheus.NewDesc(namespace+"_pgxpool_idle_conns",
+		"Idle connections in the pool.", nil, nil)
+	poolTotalConns = prometheus.NewDesc(namespace+"_pgxpool_total_conns",
+		"All connections in the pool.", nil, nil)
+	poolMaxConns = prometheus.NewDesc(namespace+"_pgxpool_max_conns",
+		"Maximum size of the pool.", nil, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_pgxpool_acquires_total",
+		"Successful connection acquires.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc(namespace+"_pgxpool_empty_acquires_total",
+		"Acquires that had to wait for a connection.", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc(namespace+"_pgxpool_canceled_acquires_total",
+		"Acquires canceled by the context.", nil, nil)
+	poolAcquireDuration = prometheus.NewDesc(namespace+"_pgxpool_acquire_duration_seconds_total",
+		"Total time spent acquiring connections.", nil, nil)
+)
+
+// poolCollector reads pgxpool statistics on every scrape.
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+}
+
+func RegisterPool(stat func() *pgxpool.Stat) {
+	prometheus.MustRegister(&poolCollector{stat: stat})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolCanceledAcquires
+	ch <- poolAcquireDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
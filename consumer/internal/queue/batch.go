@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/s-khechnev/pet-project/protos/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"slices"
+)
+
+// batch holds new books collected by a worker, a book id appears only once.
+type batch struct {
+	messages []*kafka.Message
+	events   []entity.BookEvent
+}
+
+func (b *batch) add(m *kafka.Message, event entity.BookEvent) {
+	b.messages = append(b.messages, m)
+	b.events = append(b.events, event)
+}
+
+func (b *batch) contains(id string) bool {
+	return slices.ContainsFunc(b.events, func(e entity.BookEvent) bool {
+		return e.Book.Id == id
+	})
+}
+
+// batchable decodes the message to find out whether it creates a book, other
+// events are applied one by one.
+func (c *Consumer) batchable(m *kafka.Message) (entity.BookEvent, bool) {
+	if c.workerPolicy.BatchSize <= 1 {
+		return entity.BookEvent{}, false
+	}
+
+	event, err := decodeEvent(m)
+	if err != nil || event.Type != entity.EventCreated {
+		return entity.BookEvent{}, false
+	}
+	return event, true
+}
+
+func (c *Consumer) flush(w *partitionWorkers, b *batch) {
+	messages, events := b.messages, b.events
+	*b = batch{}
+	if len(messages) == 0 {
+		return
+	}
+
+	c.withSlot(w.revoked, func() {
+		c.handleBatch(messages, events, w.revoked)
+	})
+}
+
+// handleBatch saves new books in bulk. A batch that still fails after the
+// retries falls back to handling every message on its own, so only the bad
+// books go on to the retry and dead-letter topics.
+func (c *Consumer) handleBatch(messages []*kafka.Message, events []entity.BookEvent, revoked <-chan struct{}) {
+	if len(messages) == 1 {
+		c.handleMessage(messages[0], revoked)
+		return
+	}
+
+	links := make([]trace.Link, 0, len(messages))
+	for _, m := range messages {
+		producerCtx := otel.GetTextMapPropagator().Extract(context.Background(), tracing.NewHeaderCarrier(&m.Headers))
+		links = append(links, trace.Link{SpanContext: trace.SpanContextFromContext(producerCtx)})
+	}
+	topic := *messages[0].TopicPartition.Topic
+	ctx, span := otel.Tracer(tracerName).Start(context.Background(), topic+" process batch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingBatchMessageCount(len(messages)),
+		))
+	defer span.End()
+
+	books := make([]entity.Book, 0, len(events))
+	for _, event := range events {
+		book := event.Book
+		if err := c.resolveText(&book); err != nil {
+			c.handleSeparately(messages, revoked)
+			return
+		}
+		books = append(books, book)
+	}
+
+	_, err := c.withRetries(revoked, func() error {
+		return c.bookProcessor.ProcessBatch(ctx, books)
+	}, slog.Int("batch", len(books)))
+	if err == nil {
+		for _, m := range messages {
+			c.markDone(m)
+		}
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	slog.Warn("failed to save book batch, applying books one by one",
+		slog.Int("size", len(books)),
+		slog.String("error", err.Error()))
+	c.handleSeparately(messages, revoked)
+}
+
+func (c *Consumer) handleSeparately(messages []*kafka.Message, revoked <-chan struct{}) {
+	for _, m := range messages {
+		select {
+		case <-revoked:
+			return
+		default:
+		}
+
+		c.handleMessage(m, revoked)
+	}
+}
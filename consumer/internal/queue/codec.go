@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+)
+
+const (
+	ContentTypeHeader   = "content-type"
+	SchemaVersionHeader = "schema-version"
+
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJson     = "application/json"
+	BookSchemaVersion   = "2"
+)
+
+var bookSchemaVersions = map[string]bool{
+	"1":               true,
+	BookSchemaVersion: true,
+}
+
+var (
+	ErrUnsupportedContentType   = errors.New("unsupported content type")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+	ErrUnsupportedEventType     = errors.New("unsupported event type")
+	ErrInvalidBookId            = errors.New("invalid book id")
+)
+
+func header(msg *kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// decodeEvent reads protobuf book events and, for messages produced before the
+// migration, legacy JSON books without headers. Schema version 1 and legacy
+// messages carry no event type and always create a book.
+func decodeEvent(msg *kafka.Message) (entity.BookEvent, error) {
+	var (
+		event entity.BookEvent
+		err   error
+	)
+	switch contentType := header(msg, ContentTypeHeader); contentType {
+	case ContentTypeProtobuf:
+		event, err = decodeProtobufEvent(msg)
+	case ContentTypeJson, "":
+		var book entity.Book
+		if err := json.Unmarshal(msg.Value, &book); err != nil {
+			return entity.BookEvent{}, fmt.Errorf("failed unmarshalling book from json: %w", err)
+		}
+		event = entity.BookEvent{Type: entity.EventCreated, Book: book}
+	default:
+		return entity.BookEvent{}, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+	if err != nil {
+		return entity.BookEvent{}, err
+	}
+
+	if _, err := uuid.Parse(event.Book.Id); err != nil {
+		return entity.BookEvent{}, fmt.Errorf("%w %q: %w", ErrInvalidBookId, event.Book.Id, err)
+	}
+	event.Book.Version = eventVersion(msg)
+
+	return event, nil
+}
+
+// eventVersion is the offset of the message in the book topic plus one, so
+// that zero stays unknown. Events of a book share its partition, so the
+// offset orders them regardless of producer clocks, and redelivered and
+// retried messages keep it.
+func eventVersion(msg *kafka.Message) int64 {
+	_, _, origOffset := origin(msg)
+	offset, err := strconv.ParseInt(origOffset, 10, 64)
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset + 1
+}
+
+var eventTypes = map[bookv1.EventType]entity.EventType{
+	bookv1.EventType_EVENT_TYPE_UNSPECIFIED: entity.EventCreated,
+	bookv1.EventType_EVENT_TYPE_CREATED:     entity.EventCreated,
+	bookv1.EventType_EVENT_TYPE_UPDATED:     entity.EventUpdated,
+	bookv1.EventType_EVENT_TYPE_DELETED:     entity.EventDeleted,
+}
+
+func decodeProtobufEvent(msg *kafka.Message) (entity.BookEvent, error) {
+	if version := header(msg, SchemaVersionHeader); !bookSchemaVersions[version] {
+		return entity.BookEvent{}, fmt.Errorf("%w: %q", ErrUnsupportedSchemaVersion, version)
+	}
+
+	var event bookv1.BookEvent
+	if err := proto.Unmarshal(msg.Value, &event); err != nil {
+		return entity.BookEvent{}, fmt.Errorf("failed unmarshalling book from protobuf: %w", err)
+	}
+
+	eventType, ok := eventTypes[event.GetType()]
+	if !ok {
+		return entity.BookEvent{}, fmt.Errorf("%w: %s", ErrUnsupportedEventType, event.GetType())
+	}
+
+	book := entity.Book{
+		Id:      event.GetId(),
+		Title:   event.GetTitle(),
+		Authors: event.GetAuthors(),
+		Text:    event.GetText(),
+	}
+	if ref := event.GetTextRef(); ref != nil {
+		book.TextRef = &entity.TextRef{
+			Key:    ref.GetKey(),
+			Sha256: ref.GetSha256(),
+			Size:   ref.GetSize(),
+		}
+	}
+
+	return entity.BookEvent{
+		Type:   eventType,
+		Book:   book,
+		Fields: event.GetUpdateMask(),
+	}, nil
+}
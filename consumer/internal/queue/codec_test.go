@@ -0,0 +1,175 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"testing"
+	"time"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	event, err := proto.Marshal(&bookv1.BookEvent{
+		Id:      "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+		Title:   "War and Peace",
+		Authors: []string{"Lev Tolstoi"},
+		Text:    "123",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %s", err)
+	}
+
+	update, err := proto.Marshal(&bookv1.BookEvent{
+		Id:         "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+		Title:      "War and Peace",
+		Authors:    []string{"Lev Tolstoi"},
+		Text:       "123",
+		Type:       bookv1.EventType_EVENT_TYPE_UPDATED,
+		UpdateMask: []string{"title"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %s", err)
+	}
+
+	protobufHeaders := func(version string) []kafka.Header {
+		return []kafka.Header{
+			{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)},
+			{Key: SchemaVersionHeader, Value: []byte(version)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		msg        *kafka.Message
+		expectType entity.EventType
+		expectErr  error
+	}{
+		{
+			name:       "protobuf",
+			msg:        &kafka.Message{Value: event, Headers: protobufHeaders(BookSchemaVersion)},
+			expectType: entity.EventCreated,
+		},
+		{
+			name:       "protobuf update",
+			msg:        &kafka.Message{Value: update, Headers: protobufHeaders(BookSchemaVersion)},
+			expectType: entity.EventUpdated,
+		},
+		{
+			name:       "protobuf schema version 1",
+			msg:        &kafka.Message{Value: event, Headers: protobufHeaders("1")},
+			expectType: entity.EventCreated,
+		},
+		{
+			name:       "legacy json",
+			msg:        &kafka.Message{Value: []byte(`{"id":"5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11","title":"War and Peace","authors":["Lev Tolstoi"],"text":"123"}`)},
+			expectType: entity.EventCreated,
+		},
+		{
+			name:      "unknown schema version",
+			msg:       &kafka.Message{Value: event, Headers: protobufHeaders("3")},
+			expectErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:      "legacy json without id",
+			msg:       &kafka.Message{Value: []byte(`{"title":"War and Peace"}`)},
+			expectErr: ErrInvalidBookId,
+		},
+		{
+			name:      "unknown content type",
+			msg:       &kafka.Message{Value: event, Headers: []kafka.Header{{Key: ContentTypeHeader, Value: []byte("text/plain")}}},
+			expectErr: ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := decodeEvent(tt.msg)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expect error %v, but got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to decode book: %s", err)
+			}
+
+			book := event.Book
+			if book.Title != "War and Peace" || len(book.Authors) != 1 || book.Text != "123" {
+				t.Errorf("unexpected book: %+v", book)
+			}
+			if event.Type != tt.expectType {
+				t.Errorf("expect event type %s, but got %s", tt.expectType, event.Type)
+			}
+			if event.Type == entity.EventUpdated && (len(event.Fields) != 1 || event.Fields[0] != entity.FieldTitle) {
+				t.Errorf("unexpected update fields: %v", event.Fields)
+			}
+		})
+	}
+}
+
+func TestEventVersion(t *testing.T) {
+	topic, retryTopic := "books", "books-retry-1"
+	publishedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	retried := func(offset string) *kafka.Message {
+		return &kafka.Message{
+			Headers: []kafka.Header{
+				{Key: RetryOriginalTopicHeader, Value: []byte(topic)},
+				{Key: RetryOriginalOffsetHeader, Value: []byte(offset)},
+			},
+			TopicPartition: kafka.TopicPartition{Topic: &retryTopic, Offset: 3},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		msg    *kafka.Message
+		expect int64
+	}{
+		{
+			name:   "first offset",
+			msg:    &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: 0}},
+			expect: 1,
+		},
+		{
+			name:   "offset",
+			msg:    &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: 7}},
+			expect: 8,
+		},
+		{
+			name: "timestamp is ignored",
+			msg: &kafka.Message{
+				Timestamp:      publishedAt,
+				TimestampType:  kafka.TimestampCreateTime,
+				TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: 7},
+			},
+			expect: 8,
+		},
+		{
+			name:   "large offset",
+			msg:    &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: 1<<20 + 5}},
+			expect: 1<<20 + 6,
+		},
+		{
+			name:   "retried keeps the original offset",
+			msg:    retried("9"),
+			expect: 10,
+		},
+		{
+			name:   "retried with a malformed original offset",
+			msg:    retried("nine"),
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if version := eventVersion(tt.msg); version != tt.expect {
+				t.Errorf("expect version %d, but got %d", tt.expect, version)
+			}
+		})
+	}
+}
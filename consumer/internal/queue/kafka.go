@@ -2,68 +2,227 @@ package queue
 
 import (
 	"consumer/internal/entity"
+	"consumer/internal/metrics"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/s-khechnev/pet-project/protos/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type BookProcessor interface {
-	Process(ctx context.Context, book entity.Book) error
+	Process(ctx context.Context, event entity.BookEvent) error
+	ProcessBatch(ctx context.Context, books []entity.Book) error
+}
+
+type TextStore interface {
+	Get(ref entity.TextRef) ([]byte, error)
+}
+
+type Redeliverer interface {
+	DeadLetter(ctx context.Context, topic string, msg *kafka.Message, failure Failure) error
+	Retry(ctx context.Context, topic string, msg *kafka.Message, failure Failure, notBefore time.Time) error
+}
+
+type StatusPublisher interface {
+	Publish(status entity.BookStatus) error
+}
+
+type partitionKey struct {
+	topic     string
+	partition int32
 }
 
 type Consumer struct {
-	bookProcessor BookProcessor
-	consumer      *kafka.Consumer
-	topic         string
-	context       context.Context
-	timeoutOnPoll time.Duration
+	bookProcessor   BookProcessor
+	textStore       TextStore
+	redelivery      Redeliverer
+	statusPublisher StatusPublisher
+	consumer        *kafka.Consumer
+	topic           string
+	context         context.Context
+	timeoutOnPoll   time.Duration
+
+	deadLetterTopic string
+	retry           RetryPolicy
+
+	// paused holds retry topic partitions waiting for their head message to
+	// become due, throttled holds partitions paused while their workers are
+	// busy. Both, like workers, are only touched by the poll loop.
+	paused       map[partitionKey]time.Time
+	throttled    map[partitionKey]struct{}
+	workers      map[partitionKey]*partitionWorkers
+	workerPolicy WorkerPolicy
+	slots        chan struct{}
+
+	offsets        *offsetTracker
+	commitPolicy   CommitPolicy
+	commitMu       sync.Mutex
+	commitRequests chan struct{}
+	uncommitted    atomic.Int64
+	done           chan struct{}
+
+	// joined is set once the group assigned partitions to the consumer and
+	// cleared when all of them are revoked, assigned counts them since
+	// cooperative rebalances add and revoke only a part of the assignment,
+	// lastPoll is the unix nano time of the last poll loop iteration
+	joined   atomic.Bool
+	assigned int
+	lastPoll atomic.Int64
 }
 
 func NewConsumer(
 	ctx context.Context,
 	bookProcessor BookProcessor,
+	textStore TextStore,
+	redelivery Redeliverer,
+	statusPublisher StatusPublisher,
 	topic string,
 	groupID string,
 	config *kafka.ConfigMap,
 	timeoutOnPoll time.Duration,
+	deadLetterTopic string,
+	retry RetryPolicy,
+	commitPolicy CommitPolicy,
+	workerPolicy WorkerPolicy,
 ) (*Consumer, error) {
 	err := config.SetKey("group.id", groupID)
 	if err != nil {
 		return nil, err
 	}
 
+	// offsets are committed by the consumer once messages are handled
+	err = config.SetKey("enable.auto.commit", false)
+	if err != nil {
+		return nil, err
+	}
+	if commitPolicy.Interval <= 0 {
+		commitPolicy.Interval = defaultCommitInterval
+	}
+
 	c, err := kafka.NewConsumer(config)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.Subscribe(topic, nil)
+	consumer := &Consumer{
+		consumer:        c,
+		topic:           topic,
+		bookProcessor:   bookProcessor,
+		textStore:       textStore,
+		redelivery:      redelivery,
+		statusPublisher: statusPublisher,
+		context:         ctx,
+		timeoutOnPoll:   timeoutOnPoll,
+
+		deadLetterTopic: deadLetterTopic,
+		retry:           retry,
+		paused:          make(map[partitionKey]time.Time),
+		throttled:       make(map[partitionKey]struct{}),
+		workers:         make(map[partitionKey]*partitionWorkers),
+		workerPolicy:    workerPolicy,
+		slots:           make(chan struct{}, max(workerPolicy.Concurrency, 1)),
+
+		offsets:        newOffsetTracker(),
+		commitPolicy:   commitPolicy,
+		commitRequests: make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+
+	topics := []string{topic}
+	for _, t := range retry.Topics {
+		topics = append(topics, t.Topic)
+	}
+
+	err = c.SubscribeTopics(topics, consumer.rebalance)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Consumer{
-		consumer:      c,
-		topic:         topic,
-		bookProcessor: bookProcessor,
-		context:       ctx,
-		timeoutOnPoll: timeoutOnPoll,
-	}, nil
+	return consumer, nil
+}
+
+// rebalance tracks the group membership and forgets the state of revoked
+// partitions, partitions are assigned by the library.
+func (c *Consumer) rebalance(_ *kafka.Consumer, ev kafka.Event) error {
+	switch e := ev.(type) {
+	case kafka.AssignedPartitions:
+		slog.Info("partitions assigned", slog.Int("count", len(e.Partitions)))
+		c.assigned += len(e.Partitions)
+		c.joined.Store(true)
+	case kafka.RevokedPartitions:
+		slog.Info("partitions revoked", slog.Int("count", len(e.Partitions)))
+		c.assigned = max(c.assigned-len(e.Partitions), 0)
+		if c.assigned == 0 {
+			c.joined.Store(false)
+		}
+		// the next owner starts from the committed offsets, so the events in
+		// progress are finished and committed first
+		c.stopWorkers(e.Partitions)
+		c.commitOffsets()
+		c.offsets.Remove(e.Partitions)
+		for _, p := range e.Partitions {
+			metrics.PartitionLag.DeleteLabelValues(*p.Topic, strconv.Itoa(int(p.Partition)))
+			delete(c.paused, partitionKey{topic: *p.Topic, partition: p.Partition})
+		}
+	}
+	return nil
+}
+
+// Subscribed reports whether the consumer is a member of its group.
+func (c *Consumer) Subscribed(context.Context) error {
+	if !c.joined.Load() {
+		return errors.New("consumer has not joined the group")
+	}
+	return nil
+}
+
+// Alive reports whether the poll loop made progress within maxDelay.
+func (c *Consumer) Alive(maxDelay time.Duration) error {
+	lastPoll := time.Unix(0, c.lastPoll.Load())
+	if delay := time.Since(lastPoll); delay > maxDelay {
+		return fmt.Errorf("consumer has not polled for %s", delay.Round(time.Second))
+	}
+	return nil
 }
 
+// Run polls messages until the context is done, Close must be called after.
 func (c *Consumer) Run() {
+	defer close(c.done)
 	slog.Info("consumer started")
 
+	go c.commitLoop()
+
+	var lastLagUpdate time.Time
+
 	for {
 		select {
 		case <-c.context.Done():
+			c.stopWorkers(c.assignedWorkers())
 			return
 		default:
 		}
 
-		ev := c.consumer.Poll(int(c.timeoutOnPoll))
+		now := time.Now()
+		c.lastPoll.Store(now.UnixNano())
+		if now.Sub(lastLagUpdate) >= lagUpdateInterval {
+			c.updateLag()
+			lastLagUpdate = now
+		}
+		c.resumeDue(now)
+		c.resumeThrottled()
+
+		ev := c.consumer.Poll(int(c.timeoutOnPoll.Milliseconds()))
 		if ev == nil {
 			continue
 		}
@@ -73,21 +232,13 @@ func (c *Consumer) Run() {
 			//fmt.Printf("%% Message on %s:\n%s\n",
 			//	e.TopicPartition, string(e.Value))
 
-			var book entity.Book
-			err := json.Unmarshal(e.Value, &book)
-			if err != nil {
-				slog.Error("failed unmarshalling book from json", slog.String("error", err.Error()))
+			if c.held(e) || c.postpone(e) {
+				continue
 			}
 
-			err = c.bookProcessor.Process(c.context, book)
-			if err != nil {
-				slog.Error("failed processing book", slog.String("error", err.Error()))
-			}
+			metrics.ConsumedMessages.WithLabelValues(*e.TopicPartition.Topic).Inc()
 
-			_, err = c.consumer.CommitMessage(e)
-			if err != nil {
-				slog.Error("failed commit message", slog.String("error", err.Error()))
-			}
+			c.dispatch(e)
 		case kafka.Error:
 			slog.Error("consumer error", slog.String("error", e.Error()))
 		default:
@@ -96,6 +247,389 @@ func (c *Consumer) Run() {
 
 }
 
+const tracerName = "consumer/internal/queue"
+
+// handleMessage runs on a partition worker and continues the trace started by
+// the producer. revoked is closed when the partition is revoked or the
+// consumer stops, the event being processed is finished but not retried any
+// more.
+func (c *Consumer) handleMessage(m *kafka.Message, revoked <-chan struct{}) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), tracing.NewHeaderCarrier(&m.Headers))
+	topic := *m.TopicPartition.Topic
+	ctx, span := otel.Tracer(tracerName).Start(ctx, topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(m.TopicPartition.Partition))),
+			semconv.MessagingKafkaOffset(int(m.TopicPartition.Offset)),
+			semconv.MessagingKafkaMessageKey(string(m.Key)),
+		))
+	defer span.End()
+
+	attempts := retryAttempts(m)
+	event, err := decodeEvent(m)
+	if err != nil {
+		metrics.DecodeFailures.Inc()
+		slog.Error("failed decoding book event", slog.String("error", err.Error()))
+		c.deadLetter(ctx, m, Failure{Stage: StageDecode, Err: err, Attempts: attempts + 1}, revoked)
+		return
+	}
+	span.SetAttributes(attribute.String("book.event", string(event.Type)))
+
+	tries, err := c.withRetries(revoked, func() error {
+		return c.resolveText(&event.Book)
+	}, slog.String("id", event.Book.Id))
+	if err != nil {
+		c.failed(ctx, m, Failure{Stage: StageText, Err: err, Attempts: attempts + tries}, revoked)
+		return
+	}
+
+	tries, err = c.process(ctx, event, revoked)
+	attempts += tries
+	if err == nil {
+		c.markDone(m)
+		return
+	}
+
+	slog.Error("failed processing book",
+		slog.String("id", event.Book.Id),
+		slog.Int("attempts", attempts),
+		slog.String("error", err.Error()))
+	c.failed(ctx, m, Failure{Stage: StageProcess, Err: err, Attempts: attempts}, revoked)
+}
+
+// failed moves a message that could not be handled to the next retry topic,
+// or to the dead-letter topic once the error is permanent or the retry topics
+// are over.
+func (c *Consumer) failed(ctx context.Context, m *kafka.Message, failure Failure, revoked <-chan struct{}) {
+	select {
+	case <-revoked:
+		// the message is read again by the next owner of the partition
+		return
+	default:
+	}
+
+	if c.retry.retryable(failure.Err) {
+		if next, ok := c.retry.nextTopic(*m.TopicPartition.Topic); ok {
+			c.retryLater(ctx, m, next, failure, revoked)
+			return
+		}
+	}
+	c.deadLetter(ctx, m, failure, revoked)
+}
+
+// process applies the event, retrying transient failures with backoff while
+// the partition worker waits. It returns the number of attempts made.
+func (c *Consumer) process(ctx context.Context, event entity.BookEvent, revoked <-chan struct{}) (int, error) {
+	return c.withRetries(revoked, func() error {
+		return c.bookProcessor.Process(ctx, event)
+	}, slog.String("id", event.Book.Id))
+}
+
+func (c *Consumer) withRetries(revoked <-chan struct{}, fn func() error, attrs ...any) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.retry.MaxAttempts || !c.retry.retryable(err) {
+			return attempt, err
+		}
+
+		backoff := c.retry.Backoff(attempt)
+		metrics.Retries.WithLabelValues(metrics.RetryBackoff).Inc()
+		slog.Warn("retrying book event", append(attrs,
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()))...)
+
+		select {
+		case <-time.After(backoff):
+		case <-revoked:
+			return attempt, err
+		}
+	}
+}
+
+const redeliveryTimeout = 10 * time.Second
+
+// retryLater moves the message to a delayed retry topic, so a longer outage
+// does not hold up the book topic.
+func (c *Consumer) retryLater(ctx context.Context, m *kafka.Message, next RetryTopic, failure Failure, revoked <-chan struct{}) {
+	notBefore := time.Now().Add(next.Delay)
+	sent := c.redeliver(m, revoked, func() error {
+		ctx, cancel := context.WithTimeout(ctx, redeliveryTimeout)
+		defer cancel()
+		return c.redelivery.Retry(ctx, next.Topic, m, failure, notBefore)
+	})
+	if !sent {
+		return
+	}
+
+	metrics.Retries.WithLabelValues(metrics.RetryTopic).Inc()
+	slog.Warn("message is sent to retry topic",
+		slog.String("key", string(m.Key)),
+		slog.String("retry_topic", next.Topic),
+		slog.Duration("delay", next.Delay))
+	c.markDone(m)
+}
+
+// deadLetter moves the message to the dead-letter topic. The book is failed
+// only now, earlier failures are retried.
+func (c *Consumer) deadLetter(ctx context.Context, m *kafka.Message, failure Failure, revoked <-chan struct{}) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(failure.Err)
+	span.SetStatus(codes.Error, string(failure.Stage)+" failed")
+
+	if c.deadLetterTopic == "" {
+		slog.Warn("dead-letter topic is not configured, message is dropped",
+			slog.String("key", string(m.Key)),
+			slog.Int("offset", int(m.TopicPartition.Offset)))
+		c.publishStatus(m, entity.StatusFailed, failure.Err.Error())
+		c.markDone(m)
+		return
+	}
+
+	sent := c.redeliver(m, revoked, func() error {
+		ctx, cancel := context.WithTimeout(ctx, redeliveryTimeout)
+		defer cancel()
+		return c.redelivery.DeadLetter(ctx, c.deadLetterTopic, m, failure)
+	})
+	if !sent {
+		return
+	}
+
+	metrics.DeadLetters.WithLabelValues(string(failure.Stage)).Inc()
+	slog.Warn("message is sent to dead-letter topic",
+		slog.String("key", string(m.Key)),
+		slog.String("stage", string(failure.Stage)),
+		slog.Int("attempts", failure.Attempts),
+		slog.Int("offset", int(m.TopicPartition.Offset)))
+	c.publishStatus(m, entity.StatusFailed, failure.Err.Error())
+	c.markDone(m)
+}
+
+// started tells the client a book event is being processed, retried messages
+// have published it already.
+func (c *Consumer) started(m *kafka.Message) {
+	if retryAttempts(m) == 0 {
+		c.publishStatus(m, entity.StatusProcessing, "")
+	}
+}
+
+// publishStatus publishes the status of the book the message is keyed by.
+func (c *Consumer) publishStatus(m *kafka.Message, status entity.Status, reason string) {
+	if len(m.Key) == 0 {
+		return
+	}
+
+	err := c.statusPublisher.Publish(entity.BookStatus{
+		Id:        string(m.Key),
+		Status:    status,
+		Reason:    reason,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Error("failed to publish book status",
+			slog.String("id", string(m.Key)),
+			slog.String("status", string(status)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// redeliver keeps sending the message until the broker accepts it, since
+// committing past an unsent message loses the book. The worker stalls in the
+// meantime and the partition is paused once its queue fills up.
+func (c *Consumer) redeliver(m *kafka.Message, revoked <-chan struct{}, send func() error) bool {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil {
+			return true
+		}
+
+		slog.Error("failed to redeliver message",
+			slog.String("key", string(m.Key)),
+			slog.Int("partition", int(m.TopicPartition.Partition)),
+			slog.Int("offset", int(m.TopicPartition.Offset)),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()))
+
+		select {
+		case <-time.After(max(c.retry.Backoff(attempt), time.Second)):
+		case <-revoked:
+			return false
+		}
+	}
+}
+
+// held skips messages of paused partitions that were fetched before the pause
+// took effect, they are read again once the partition is resumed.
+func (c *Consumer) held(m *kafka.Message) bool {
+	key := partitionKey{topic: *m.TopicPartition.Topic, partition: m.TopicPartition.Partition}
+	_, paused := c.paused[key]
+	_, throttled := c.throttled[key]
+	return paused || throttled
+}
+
+// pause stops fetching the partition of the message and rewinds it to the message.
+func (c *Consumer) pause(m *kafka.Message) bool {
+	partition := []kafka.TopicPartition{{Topic: m.TopicPartition.Topic, Partition: m.TopicPartition.Partition}}
+	if err := c.consumer.Pause(partition); err != nil {
+		slog.Error("failed to pause partition", slog.String("error", err.Error()))
+		return false
+	}
+	if err := c.consumer.Seek(m.TopicPartition, 0); err != nil {
+		slog.Error("failed to rewind partition", slog.String("error", err.Error()))
+		if err := c.consumer.Resume(partition); err != nil {
+			slog.Error("failed to resume partition", slog.String("error", err.Error()))
+		}
+		return false
+	}
+	return true
+}
+
+// postpone pauses a retry topic partition until its head message is due, the
+// message is read again once the partition is resumed.
+func (c *Consumer) postpone(m *kafka.Message) bool {
+	notBefore, ok := retryNotBefore(m)
+	if !ok || !time.Now().Before(notBefore) {
+		return false
+	}
+	if !c.pause(m) {
+		return false
+	}
+
+	key := partitionKey{topic: *m.TopicPartition.Topic, partition: m.TopicPartition.Partition}
+	c.paused[key] = notBefore
+	slog.Debug("retry partition is paused",
+		slog.String("topic", key.topic),
+		slog.Int("partition", int(key.partition)),
+		slog.Time("until", notBefore))
+	return true
+}
+
+func (c *Consumer) resumeDue(now time.Time) {
+	for key, notBefore := range c.paused {
+		if now.Before(notBefore) {
+			continue
+		}
+
+		partition := []kafka.TopicPartition{{Topic: &key.topic, Partition: key.partition}}
+		if err := c.consumer.Resume(partition); err != nil {
+			slog.Error("failed to resume retry partition", slog.String("error", err.Error()))
+			continue
+		}
+		delete(c.paused, key)
+	}
+}
+
+// markDone lets the commit position move past the message. Offsets are
+// committed in the background every Interval or after Batch messages.
+func (c *Consumer) markDone(m *kafka.Message) {
+	c.offsets.Done(m.TopicPartition)
+
+	if c.commitPolicy.Batch > 0 && c.uncommitted.Add(1) >= int64(c.commitPolicy.Batch) {
+		c.uncommitted.Store(0)
+		select {
+		case c.commitRequests <- struct{}{}:
+		default:
+			// a commit is already requested
+		}
+	}
+}
+
+func (c *Consumer) commitLoop() {
+	ticker := time.NewTicker(c.commitPolicy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.context.Done():
+			return
+		case <-ticker.C:
+		case <-c.commitRequests:
+		}
+		c.commitOffsets()
+	}
+}
+
+// commitOffsets synchronously commits the offsets of handled messages. The
+// lock keeps an older commit from landing after a newer one.
+func (c *Consumer) commitOffsets() {
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+
+	offsets := c.offsets.Commitable()
+	if len(offsets) == 0 {
+		return
+	}
+
+	committed, err := c.consumer.CommitOffsets(offsets)
+	if err != nil {
+		metrics.CommitFailures.Inc()
+		slog.Error("failed commit offsets", slog.String("error", err.Error()))
+		return
+	}
+	for _, tp := range committed {
+		if tp.Error != nil {
+			metrics.CommitFailures.Inc()
+			slog.Error("failed commit partition offset",
+				slog.String("topic", *tp.Topic),
+				slog.Int("partition", int(tp.Partition)),
+				slog.String("error", tp.Error.Error()))
+		}
+	}
+	c.offsets.Committed(committed)
+}
+
+const lagUpdateInterval = 10 * time.Second
+
+// updateLag reports the lag of assigned partitions using the high watermarks
+// cached from fetch responses, so it does not block the poll loop.
+func (c *Consumer) updateLag() {
+	assignment, err := c.consumer.Assignment()
+	if err != nil {
+		slog.Error("failed to get assignment", slog.String("error", err.Error()))
+		return
+	}
+
+	positions, err := c.consumer.Position(assignment)
+	if err != nil {
+		slog.Error("failed to get positions", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, p := range positions {
+		_, high, err := c.consumer.GetWatermarkOffsets(*p.Topic, p.Partition)
+		if err != nil || p.Offset < 0 || high < 0 {
+			// nothing is fetched from the partition yet
+			continue
+		}
+		metrics.PartitionLag.WithLabelValues(*p.Topic, strconv.Itoa(int(p.Partition))).Set(float64(high - int64(p.Offset)))
+	}
+}
+
+func (c *Consumer) resolveText(book *entity.Book) error {
+	if book.TextRef == nil {
+		return nil
+	}
+
+	text, err := c.textStore.Get(*book.TextRef)
+	if err != nil {
+		slog.Error("failed resolving book text",
+			slog.String("id", book.Id),
+			slog.String("key", book.TextRef.Key),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	book.Text = string(text)
+	book.TextRef = nil
+
+	return nil
+}
+
+// Close waits for Run to return, commits the handled messages and leaves the group.
 func (c *Consumer) Close() error {
+	<-c.done
+	c.commitOffsets()
 	return c.consumer.Close()
 }
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"testing"
+	"time"
+)
+
+func TestRebalanceMembership(t *testing.T) {
+	topic := "books"
+	partitions := func(ids ...int32) []kafka.TopicPartition {
+		tps := make([]kafka.TopicPartition, 0, len(ids))
+		for _, id := range ids {
+			tps = append(tps, kafka.TopicPartition{Topic: &topic, Partition: id})
+		}
+		return tps
+	}
+
+	tests := []struct {
+		name   string
+		events []kafka.Event
+		joined bool
+	}{
+		{
+			name:   "assigned",
+			events: []kafka.Event{kafka.AssignedPartitions{Partitions: partitions(0, 1)}},
+			joined: true,
+		},
+		{
+			name: "eager revoke",
+			events: []kafka.Event{
+				kafka.AssignedPartitions{Partitions: partitions(0, 1)},
+				kafka.RevokedPartitions{Partitions: partitions(0, 1)},
+			},
+			joined: false,
+		},
+		{
+			name: "cooperative partial revoke",
+			events: []kafka.Event{
+				kafka.AssignedPartitions{Partitions: partitions(0, 1)},
+				kafka.RevokedPartitions{Partitions: partitions(1)},
+			},
+			joined: true,
+		},
+		{
+			name: "cooperative incremental revokes",
+			events: []kafka.Event{
+				kafka.AssignedPartitions{Partitions: partitions(0)},
+				kafka.AssignedPartitions{Partitions: partitions(1, 2)},
+				kafka.RevokedPartitions{Partitions: partitions(0, 2)},
+				kafka.RevokedPartitions{Partitions: partitions(1)},
+			},
+			joined: false,
+		},
+		{
+			name: "reassigned after revoke",
+			events: []kafka.Event{
+				kafka.AssignedPartitions{Partitions: partitions(0)},
+				kafka.RevokedPartitions{Partitions: partitions(0)},
+				kafka.AssignedPartitions{Partitions: partitions(1)},
+			},
+			joined: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Consumer{
+				offsets:   newOffsetTracker(),
+				paused:    make(map[partitionKey]time.Time),
+				throttled: make(map[partitionKey]struct{}),
+				workers:   make(map[partitionKey]*partitionWorkers),
+			}
+
+			for _, ev := range tt.events {
+				if err := c.rebalance(nil, ev); err != nil {
+					t.Fatalf("expect no error, but got %v", err)
+				}
+			}
+
+			if joined := c.Subscribed(context.Background()) == nil; joined != tt.joined {
+				t.Errorf("expect joined %v, but got %v", tt.joined, joined)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"sync"
+	"time"
+)
+
+// CommitPolicy commits offsets every Interval or as soon as Batch messages
+// are handled, whichever comes first.
+type CommitPolicy struct {
+	Interval time.Duration
+	Batch    int
+}
+
+const defaultCommitInterval = 5 * time.Second
+
+type partitionOffsets struct {
+	// pending are offsets read from the partition but not handled yet
+	pending   map[kafka.Offset]struct{}
+	next      kafka.Offset
+	committed kafka.Offset
+}
+
+// commitOffset is the offset to commit: the oldest pending message, so a
+// restart never skips it, or the one after the last handled message.
+func (p *partitionOffsets) commitOffset() kafka.Offset {
+	offset := kafka.Offset(-1)
+	for pending := range p.pending {
+		if offset < 0 || pending < offset {
+			offset = pending
+		}
+	}
+	if offset < 0 {
+		offset = p.next
+	}
+	return offset
+}
+
+// offsetTracker keeps the commit position of every assigned partition.
+// Messages of a partition are begun in the order they are read, but may be
+// done out of order.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[partitionKey]*partitionOffsets)}
+}
+
+func (t *offsetTracker) partition(tp kafka.TopicPartition) *partitionOffsets {
+	key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{pending: make(map[kafka.Offset]struct{}), committed: kafka.OffsetInvalid}
+		t.partitions[key] = p
+	}
+	return p
+}
+
+func (t *offsetTracker) Begin(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partition(tp).pending[tp.Offset] = struct{}{}
+}
+
+// Abort forgets a begun message that was not handed to a worker, it will be
+// read again.
+func (t *offsetTracker) Abort(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.partition(tp).pending, tp.Offset)
+}
+
+func (t *offsetTracker) Done(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partition(tp)
+	delete(p.pending, tp.Offset)
+	p.next = max(p.next, tp.Offset+1)
+}
+
+// Commitable returns the partitions whose commit position moved since the
+// last commit.
+func (t *offsetTracker) Commitable() []kafka.TopicPartition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var offsets []kafka.TopicPartition
+	for key, p := range t.partitions {
+		if p.next == 0 {
+			// nothing is handled yet
+			continue
+		}
+		offset := p.commitOffset()
+		if offset <= p.committed {
+			continue
+		}
+		topic := key.topic
+		offsets = append(offsets, kafka.TopicPartition{Topic: &topic, Partition: key.partition, Offset: offset})
+	}
+	return offsets
+}
+
+func (t *offsetTracker) Committed(offsets []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range offsets {
+		if tp.Error != nil {
+			continue
+		}
+		if p, ok := t.partitions[partitionKey{topic: *tp.Topic, partition: tp.Partition}]; ok {
+			p.committed = max(p.committed, tp.Offset)
+		}
+	}
+}
+
+func (t *offsetTracker) Remove(partitions []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range partitions {
+		delete(t.partitions, partitionKey{topic: *tp.Topic, partition: tp.Partition})
+	}
+}
@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"testing"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	topic := "books"
+	message := func(partition int32, offset kafka.Offset) kafka.TopicPartition {
+		return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+	}
+	commitable := func(tracker *offsetTracker) map[int32]kafka.Offset {
+		offsets := make(map[int32]kafka.Offset)
+		for _, tp := range tracker.Commitable() {
+			offsets[tp.Partition] = tp.Offset
+		}
+		return offsets
+	}
+
+	tracker := newOffsetTracker()
+	for offset := kafka.Offset(10); offset < 13; offset++ {
+		tracker.Begin(message(0, offset))
+	}
+	tracker.Begin(message(1, 5))
+
+	if offsets := commitable(tracker); len(offsets) != 0 {
+		t.Fatalf("expect nothing to commit before any message is done, got %v", offsets)
+	}
+
+	// a later message is done first, the commit must not pass offset 10
+	tracker.Done(message(0, 11))
+	if offsets := commitable(tracker); len(offsets) != 1 || offsets[0] != 10 {
+		t.Fatalf("expect commit to stay at offset 10, got %v", offsets)
+	}
+
+	tracker.Done(message(0, 10))
+	tracker.Done(message(1, 5))
+	offsets := commitable(tracker)
+	if offsets[0] != 12 || offsets[1] != 6 {
+		t.Fatalf("expect offsets 12 and 6, got %v", offsets)
+	}
+
+	tracker.Committed([]kafka.TopicPartition{
+		{Topic: &topic, Partition: 0, Offset: 12},
+		{Topic: &topic, Partition: 1, Offset: 6, Error: errors.New("commit failed")},
+	})
+	offsets = commitable(tracker)
+	if _, ok := offsets[0]; ok || offsets[1] != 6 {
+		t.Fatalf("expect only the failed partition to be commitable, got %v", offsets)
+	}
+
+	tracker.Done(message(0, 12))
+	tracker.Remove([]kafka.TopicPartition{{Topic: &topic, Partition: 1}})
+	offsets = commitable(tracker)
+	if len(offsets) != 1 || offsets[0] != 13 {
+		t.Fatalf("expect offset 13 of partition 0, got %v", offsets)
+	}
+
+	// an aborted message is read again, the commit stops right before it
+	tracker.Begin(message(0, 13))
+	tracker.Begin(message(0, 14))
+	tracker.Abort(message(0, 14))
+	tracker.Done(message(0, 13))
+	offsets = commitable(tracker)
+	if len(offsets) != 1 || offsets[0] != 14 {
+		t.Fatalf("expect offset 14 of partition 0, got %v", offsets)
+	}
+}
@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Headers added to a dead-lettered message, the original headers are kept.
+const (
+	DeadLetterErrorHeader     = "dlq-error"
+	DeadLetterStageHeader     = "dlq-stage"
+	DeadLetterTopicHeader     = "dlq-original-topic"
+	DeadLetterPartitionHeader = "dlq-original-partition"
+	DeadLetterOffsetHeader    = "dlq-original-offset"
+	DeadLetterAttemptsHeader  = "dlq-attempts"
+	DeadLetterFailedAtHeader  = "dlq-failed-at"
+)
+
+// Headers of a message scheduled for a delayed retry. The original position
+// is set once, when the message leaves the book topic.
+const (
+	RetryAttemptsHeader          = "retry-attempts"
+	RetryNotBeforeHeader         = "retry-not-before"
+	RetryErrorHeader             = "retry-error"
+	RetryOriginalTopicHeader     = "retry-original-topic"
+	RetryOriginalPartitionHeader = "retry-original-partition"
+	RetryOriginalOffsetHeader    = "retry-original-offset"
+)
+
+// Stage tells where the handling of a message failed.
+type Stage string
+
+const (
+	StageDecode  Stage = "decode"
+	StageText    Stage = "text"
+	StageProcess Stage = "process"
+)
+
+type Failure struct {
+	Stage    Stage
+	Err      error
+	Attempts int
+}
+
+var ErrRedeliveryTimeout = errors.New("redelivered message is not acknowledged in time")
+
+// RedeliveryProducer moves failed messages to the retry and dead-letter topics.
+type RedeliveryProducer struct {
+	producer     *kafka.Producer
+	flushTimeout int
+}
+
+func NewRedeliveryProducer(config *kafka.ConfigMap, flushTimeout int) (*RedeliveryProducer, error) {
+	producer, err := kafka.NewProducer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for e := range producer.Events() {
+			if ev, ok := e.(kafka.Error); ok {
+				slog.Error("kafka error", slog.String("error", ev.Error()))
+			}
+		}
+	}()
+
+	return &RedeliveryProducer{
+		producer:     producer,
+		flushTimeout: flushTimeout,
+	}, nil
+}
+
+func (p *RedeliveryProducer) DeadLetter(ctx context.Context, topic string, msg *kafka.Message, failure Failure) error {
+	return p.send(ctx, deadLetterMessage(msg, topic, failure, time.Now()))
+}
+
+func (p *RedeliveryProducer) Retry(ctx context.Context, topic string, msg *kafka.Message, failure Failure, notBefore time.Time) error {
+	return p.send(ctx, retryMessage(msg, topic, failure, notBefore))
+}
+
+// send waits for the broker to acknowledge the message, the caller commits
+// the original offset only after.
+func (p *RedeliveryProducer) send(ctx context.Context, msg *kafka.Message) error {
+	delivered := make(chan kafka.Event, 1)
+	if err := p.producer.Produce(msg, delivered); err != nil {
+		return err
+	}
+
+	select {
+	case e := <-delivered:
+		if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+			return m.TopicPartition.Error
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrRedeliveryTimeout, ctx.Err())
+	}
+}
+
+func (p *RedeliveryProducer) Close() {
+	num := p.producer.Flush(p.flushTimeout)
+	slog.Info("number of outstanding redelivered messages", slog.Int("num", num))
+	p.producer.Close()
+}
+
+// origin is the position of the message in the book topic, before it went
+// through any retry topics.
+func origin(msg *kafka.Message) (topic, partition, offset string) {
+	if topic = header(msg, RetryOriginalTopicHeader); topic != "" {
+		return topic, header(msg, RetryOriginalPartitionHeader), header(msg, RetryOriginalOffsetHeader)
+	}
+
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+	return topic,
+		strconv.Itoa(int(msg.TopicPartition.Partition)),
+		strconv.FormatInt(int64(msg.TopicPartition.Offset), 10)
+}
+
+func deadLetterMessage(msg *kafka.Message, topic string, failure Failure, failedAt time.Time) *kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+7)
+	headers = append(headers, msg.Headers...)
+
+	originalTopic, partition, offset := origin(msg)
+	headers = append(headers,
+		kafka.Header{Key: DeadLetterErrorHeader, Value: []byte(failure.Err.Error())},
+		kafka.Header{Key: DeadLetterStageHeader, Value: []byte(failure.Stage)},
+		kafka.Header{Key: DeadLetterTopicHeader, Value: []byte(originalTopic)},
+		kafka.Header{Key: DeadLetterPartitionHeader, Value: []byte(partition)},
+		kafka.Header{Key: DeadLetterOffsetHeader, Value: []byte(offset)},
+		kafka.Header{Key: DeadLetterAttemptsHeader, Value: []byte(strconv.Itoa(failure.Attempts))},
+		kafka.Header{Key: DeadLetterFailedAtHeader, Value: []byte(failedAt.UTC().Format(time.RFC3339Nano))},
+	)
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+		Timestamp:      msg.Timestamp,
+	}
+}
+
+var retryHeaders = map[string]bool{
+	RetryAttemptsHeader:  true,
+	RetryNotBeforeHeader: true,
+	RetryErrorHeader:     true,
+}
+
+func retryMessage(msg *kafka.Message, topic string, failure Failure, notBefore time.Time) *kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	for _, h := range msg.Headers {
+		if !retryHeaders[h.Key] {
+			headers = append(headers, h)
+		}
+	}
+
+	if header(msg, RetryOriginalTopicHeader) == "" {
+		originalTopic, partition, offset := origin(msg)
+		headers = append(headers,
+			kafka.Header{Key: RetryOriginalTopicHeader, Value: []byte(originalTopic)},
+			kafka.Header{Key: RetryOriginalPartitionHeader, Value: []byte(partition)},
+			kafka.Header{Key: RetryOriginalOffsetHeader, Value: []byte(offset)},
+		)
+	}
+	headers = append(headers,
+		kafka.Header{Key: RetryAttemptsHeader, Value: []byte(strconv.Itoa(failure.Attempts))},
+		kafka.Header{Key: RetryNotBeforeHeader, Value: []byte(notBefore.UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: RetryErrorHeader, Value: []byte(failure.Err.Error())},
+	)
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+		Timestamp:      msg.Timestamp,
+	}
+}
+
+// retryAttempts is the number of attempts made before the message was moved
+// to the retry topic it is read from.
+func retryAttempts(msg *kafka.Message) int {
+	attempts, err := strconv.Atoi(header(msg, RetryAttemptsHeader))
+	if err != nil {
+		return 0
+	}
+	return attempts
+}
+
+func retryNotBefore(msg *kafka.Message) (time.Time, bool) {
+	notBefore, err := time.Parse(time.RFC3339Nano, header(msg, RetryNotBeforeHeader))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return notBefore, true
+}
@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterMessage(t *testing.T) {
+	topic := "books"
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 2, Offset: 42},
+		Key:            []byte("5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11"),
+		Value:          []byte("garbage"),
+		Headers:        []kafka.Header{{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)}},
+	}
+	failedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dead := deadLetterMessage(msg, "books.dlq", Failure{
+		Stage:    StageDecode,
+		Err:      errors.New("failed unmarshalling book"),
+		Attempts: 3,
+	}, failedAt)
+
+	if *dead.TopicPartition.Topic != "books.dlq" || dead.TopicPartition.Partition != kafka.PartitionAny {
+		t.Errorf("unexpected destination %v", dead.TopicPartition)
+	}
+	if string(dead.Key) != string(msg.Key) || string(dead.Value) != string(msg.Value) {
+		t.Errorf("expect key and value to be kept")
+	}
+
+	expect := map[string]string{
+		ContentTypeHeader:         ContentTypeProtobuf,
+		DeadLetterErrorHeader:     "failed unmarshalling book",
+		DeadLetterStageHeader:     "decode",
+		DeadLetterTopicHeader:     "books",
+		DeadLetterPartitionHeader: "2",
+		DeadLetterOffsetHeader:    "42",
+		DeadLetterAttemptsHeader:  "3",
+		DeadLetterFailedAtHeader:  "2025-01-02T03:04:05Z",
+	}
+	for key, value := range expect {
+		if got := header(dead, key); got != value {
+			t.Errorf("expect header %s to be %q, but got %q", key, value, got)
+		}
+	}
+	if len(msg.Headers) != 1 {
+		t.Errorf("expect original headers to be left untouched, got %d", len(msg.Headers))
+	}
+}
+
+func TestRetryMessage(t *testing.T) {
+	topic := "books"
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 1, Offset: 7},
+		Key:            []byte("5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11"),
+		Value:          []byte("book"),
+		Headers:        []kafka.Header{{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)}},
+	}
+	notBefore := time.Date(2025, 1, 2, 3, 5, 0, 0, time.UTC)
+
+	first := retryMessage(msg, "books.retry.1m", Failure{
+		Stage:    StageProcess,
+		Err:      errors.New("connection refused"),
+		Attempts: 4,
+	}, notBefore)
+
+	retryTopic := "books.retry.1m"
+	first.TopicPartition = kafka.TopicPartition{Topic: &retryTopic, Partition: 0, Offset: 3}
+	second := retryMessage(first, "books.retry.10m", Failure{
+		Stage:    StageProcess,
+		Err:      errors.New("deadlock detected"),
+		Attempts: 8,
+	}, notBefore.Add(10*time.Minute))
+
+	if *second.TopicPartition.Topic != "books.retry.10m" {
+		t.Errorf("unexpected destination %s", *second.TopicPartition.Topic)
+	}
+	if attempts := retryAttempts(second); attempts != 8 {
+		t.Errorf("expect 8 attempts, but got %d", attempts)
+	}
+	if due, ok := retryNotBefore(second); !ok || !due.Equal(notBefore.Add(10*time.Minute)) {
+		t.Errorf("unexpected not before %s", due)
+	}
+
+	counts := make(map[string]int)
+	for _, h := range second.Headers {
+		counts[h.Key]++
+	}
+	for _, key := range []string{RetryAttemptsHeader, RetryErrorHeader, RetryNotBeforeHeader, RetryOriginalTopicHeader} {
+		if counts[key] != 1 {
+			t.Errorf("expect a single %s header, but got %d", key, counts[key])
+		}
+	}
+
+	dead := deadLetterMessage(second, "books.dlq", Failure{Stage: StageProcess, Err: errors.New("deadlock detected"), Attempts: 12}, notBefore)
+	if originalTopic := header(dead, DeadLetterTopicHeader); originalTopic != "books" {
+		t.Errorf("expect original topic books, but got %s", originalTopic)
+	}
+	if offset := header(dead, DeadLetterOffsetHeader); offset != "7" {
+		t.Errorf("expect original offset 7, but got %s", offset)
+	}
+}
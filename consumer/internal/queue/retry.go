@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type RetryTopic struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryPolicy retries a failed book event in process with exponential
+// backoff, then moves it through the delayed retry topics in order. Only
+// errors accepted by Retryable are retried, others go straight to the
+// dead-letter topic.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of the backoff added or subtracted at random.
+	Jitter    float64
+	Topics    []RetryTopic
+	Retryable func(error) bool
+}
+
+// Backoff is the delay before the attempt that follows the given one.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := max(p.Multiplier, 1)
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		backoff = min(backoff, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable != nil && p.Retryable(err)
+}
+
+// nextTopic is the retry topic that follows the one the message is read from,
+// messages of the book topic go to the first one.
+func (p RetryPolicy) nextTopic(current string) (RetryTopic, bool) {
+	next := 0
+	for i, t := range p.Topics {
+		if t.Topic == current {
+			next = i + 1
+			break
+		}
+	}
+	if next >= len(p.Topics) {
+		return RetryTopic{}, false
+	}
+	return p.Topics[next], true
+}
@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	expect := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, backoff := range expect {
+		if got := policy.Backoff(i + 1); got != backoff {
+			t.Errorf("expect backoff %s after attempt %d, but got %s", backoff, i+1, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(2); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("backoff %s is out of jitter bounds", got)
+		}
+	}
+}
+
+func TestNextTopic(t *testing.T) {
+	policy := RetryPolicy{Topics: []RetryTopic{
+		{Topic: "books.retry.1m", Delay: time.Minute},
+		{Topic: "books.retry.10m", Delay: 10 * time.Minute},
+	}}
+
+	tests := []struct {
+		current string
+		expect  string
+	}{
+		{current: "books", expect: "books.retry.1m"},
+		{current: "books.retry.1m", expect: "books.retry.10m"},
+		{current: "books.retry.10m", expect: ""},
+	}
+
+	for _, tt := range tests {
+		next, ok := policy.nextTopic(tt.current)
+		if ok != (tt.expect != "") || next.Topic != tt.expect {
+			t.Errorf("expect %q after %s, but got %q", tt.expect, tt.current, next.Topic)
+		}
+	}
+
+	if _, ok := (RetryPolicy{}).nextTopic("books"); ok {
+		t.Errorf("expect no retry topic without configured topics")
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"encoding/json"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"time"
+)
+
+type StatusProducer struct {
+	producer     *kafka.Producer
+	topic        string
+	flushTimeout int
+}
+
+func NewStatusProducer(topic string, config *kafka.ConfigMap, flushTimeout int) (*StatusProducer, error) {
+	producer, err := kafka.NewProducer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for e := range producer.Events() {
+			switch ev := e.(type) {
+			case *kafka.Message:
+				if ev.TopicPartition.Error != nil {
+					slog.Error("failed to deliver book status",
+						slog.String("book_id", string(ev.Key)),
+						slog.String("error", ev.TopicPartition.Error.Error()))
+				}
+			case kafka.Error:
+				slog.Error("kafka error", slog.String("error", ev.Error()))
+			}
+		}
+	}()
+
+	return &StatusProducer{
+		producer:     producer,
+		topic:        topic,
+		flushTimeout: flushTimeout,
+	}, nil
+}
+
+func (p *StatusProducer) Publish(status entity.BookStatus) error {
+	statusJson, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return p.producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
+		Key:            []byte(status.Id),
+		Value:          statusJson,
+		Timestamp:      time.Now(),
+	}, nil)
+}
+
+func (p *StatusProducer) Close() {
+	num := p.producer.Flush(p.flushTimeout)
+	slog.Info("number of outstanding status events", slog.Int("num", num))
+	p.producer.Close()
+}
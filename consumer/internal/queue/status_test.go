@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"context"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingPublisher struct {
+	mu       sync.Mutex
+	statuses []entity.Status
+}
+
+func (p *recordingPublisher) Publish(status entity.BookStatus) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, status.Status)
+	return nil
+}
+
+type failingProcessor struct {
+	err error
+}
+
+func (p failingProcessor) Process(context.Context, entity.BookEvent) error {
+	return p.err
+}
+
+func (p failingProcessor) ProcessBatch(context.Context, []entity.Book) error {
+	return p.err
+}
+
+type recordingRedeliverer struct {
+	topics []string
+}
+
+func (r *recordingRedeliverer) DeadLetter(_ context.Context, topic string, _ *kafka.Message, _ Failure) error {
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+func (r *recordingRedeliverer) Retry(_ context.Context, topic string, _ *kafka.Message, _ Failure, _ time.Time) error {
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+func TestStatuses(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+
+	value, err := proto.Marshal(&bookv1.BookEvent{
+		Id:    "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+		Title: "War and Peace",
+		Type:  bookv1.EventType_EVENT_TYPE_UPDATED,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %s", err)
+	}
+
+	topic, retryTopic, deadLetterTopic := "books", "books-retry-1", "books-dlq"
+
+	tests := []struct {
+		name           string
+		topic          string
+		attempts       string
+		err            error
+		expectStatuses []entity.Status
+		expectTopics   []string
+	}{
+		{
+			name:           "applied",
+			topic:          topic,
+			expectStatuses: []entity.Status{entity.StatusProcessing},
+		},
+		{
+			name:           "retried later",
+			topic:          topic,
+			err:            errTransient,
+			expectStatuses: []entity.Status{entity.StatusProcessing},
+			expectTopics:   []string{retryTopic},
+		},
+		{
+			name:           "dead-lettered",
+			topic:          topic,
+			err:            errPermanent,
+			expectStatuses: []entity.Status{entity.StatusProcessing, entity.StatusFailed},
+			expectTopics:   []string{deadLetterTopic},
+		},
+		{
+			name:     "applied on retry",
+			topic:    retryTopic,
+			attempts: "1",
+		},
+		{
+			name:           "dead-lettered after retries",
+			topic:          retryTopic,
+			attempts:       "1",
+			err:            errTransient,
+			expectStatuses: []entity.Status{entity.StatusFailed},
+			expectTopics:   []string{deadLetterTopic},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			redelivery := &recordingRedeliverer{}
+			c := &Consumer{
+				bookProcessor:   failingProcessor{err: tt.err},
+				redelivery:      redelivery,
+				statusPublisher: publisher,
+				deadLetterTopic: deadLetterTopic,
+				retry: RetryPolicy{
+					MaxAttempts: 1,
+					Topics:      []RetryTopic{{Topic: retryTopic}},
+					Retryable: func(err error) bool {
+						return errors.Is(err, errTransient)
+					},
+				},
+				offsets:        newOffsetTracker(),
+				commitRequests: make(chan struct{}, 1),
+			}
+
+			msg := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &tt.topic, Offset: 7},
+				Key:            []byte("5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11"),
+				Value:          value,
+				Headers: []kafka.Header{
+					{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)},
+					{Key: SchemaVersionHeader, Value: []byte(BookSchemaVersion)},
+				},
+			}
+			if tt.attempts != "" {
+				msg.Headers = append(msg.Headers, kafka.Header{Key: RetryAttemptsHeader, Value: []byte(tt.attempts)})
+			}
+
+			c.offsets.Begin(msg.TopicPartition)
+			c.started(msg)
+			c.handleMessage(msg, make(chan struct{}))
+
+			if !slices.Equal(publisher.statuses, tt.expectStatuses) {
+				t.Errorf("expect statuses %v, but got %v", tt.expectStatuses, publisher.statuses)
+			}
+			if !slices.Equal(redelivery.topics, tt.expectTopics) {
+				t.Errorf("expect redelivery to %v, but got %v", tt.expectTopics, redelivery.topics)
+			}
+		})
+	}
+}
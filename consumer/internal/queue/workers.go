@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// WorkerPolicy spreads the messages of every assigned partition over
+// KeyWorkers workers by key hash, so books with the same id are applied in
+// order. Each worker has a queue of QueueSize messages, and at most
+// Concurrency events or batches are processed at once across all partitions.
+// Workers collect new books into batches of up to BatchSize, a batch is saved
+// once it is full or BatchLinger after its first book.
+type WorkerPolicy struct {
+	Concurrency int
+	KeyWorkers  int
+	QueueSize   int
+	BatchSize   int
+	BatchLinger time.Duration
+}
+
+type partitionWorkers struct {
+	queues []chan *kafka.Message
+	// revoked tells the workers to drop queued messages, they stay
+	// uncommitted and are read again by the next owner of the partition
+	revoked chan struct{}
+	wg      sync.WaitGroup
+}
+
+func (c *Consumer) startWorkers(key partitionKey) *partitionWorkers {
+	w := &partitionWorkers{
+		queues:  make([]chan *kafka.Message, max(c.workerPolicy.KeyWorkers, 1)),
+		revoked: make(chan struct{}),
+	}
+	for i := range w.queues {
+		w.queues[i] = make(chan *kafka.Message, max(c.workerPolicy.QueueSize, 1))
+		w.wg.Add(1)
+		go c.work(w, w.queues[i])
+	}
+
+	c.workers[key] = w
+	return w
+}
+
+func (c *Consumer) work(w *partitionWorkers, queue <-chan *kafka.Message) {
+	defer w.wg.Done()
+
+	var (
+		b      batch
+		linger <-chan time.Time
+	)
+	for {
+		select {
+		case m, ok := <-queue:
+			if !ok {
+				// revoked, the batch stays uncommitted
+				return
+			}
+			select {
+			case <-w.revoked:
+				continue
+			default:
+			}
+			c.started(m)
+
+			if event, ok := c.batchable(m); ok {
+				if b.contains(event.Book.Id) {
+					c.flush(w, &b)
+				}
+				if len(b.messages) == 0 {
+					linger = time.After(c.workerPolicy.BatchLinger)
+				}
+				b.add(m, event)
+				if len(b.messages) >= c.workerPolicy.BatchSize {
+					c.flush(w, &b)
+				}
+				continue
+			}
+
+			c.flush(w, &b)
+			c.withSlot(w.revoked, func() {
+				c.handleMessage(m, w.revoked)
+			})
+		case <-linger:
+			c.flush(w, &b)
+		}
+	}
+}
+
+func (c *Consumer) withSlot(revoked <-chan struct{}, fn func()) {
+	select {
+	case <-revoked:
+		return
+	case c.slots <- struct{}{}:
+	}
+
+	fn()
+	<-c.slots
+}
+
+// dispatch hands the message to the worker of its key. When the queue is
+// full the partition is paused and rewound to the message, which is read
+// again once the workers catch up.
+func (c *Consumer) dispatch(m *kafka.Message) {
+	key := partitionKey{topic: *m.TopicPartition.Topic, partition: m.TopicPartition.Partition}
+	w, ok := c.workers[key]
+	if !ok {
+		w = c.startWorkers(key)
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write(m.Key)
+	queue := w.queues[hash.Sum32()%uint32(len(w.queues))]
+
+	// begun before the send, a worker may be done with the message right away
+	c.offsets.Begin(m.TopicPartition)
+	select {
+	case queue <- m:
+		return
+	default:
+	}
+
+	if c.pause(m) {
+		c.offsets.Abort(m.TopicPartition)
+		c.throttled[key] = struct{}{}
+		slog.Debug("partition is paused, workers are busy",
+			slog.String("topic", key.topic),
+			slog.Int("partition", int(key.partition)))
+		return
+	}
+
+	// the partition could not be paused, wait for the worker instead
+	queue <- m
+}
+
+// resumeThrottled resumes paused partitions whose queues drained to half.
+func (c *Consumer) resumeThrottled() {
+	for key := range c.throttled {
+		w := c.workers[key]
+		if w != nil && !w.drained() {
+			continue
+		}
+
+		partition := []kafka.TopicPartition{{Topic: &key.topic, Partition: key.partition}}
+		if err := c.consumer.Resume(partition); err != nil {
+			slog.Error("failed to resume partition", slog.String("error", err.Error()))
+			continue
+		}
+		delete(c.throttled, key)
+	}
+}
+
+func (w *partitionWorkers) drained() bool {
+	for _, queue := range w.queues {
+		if len(queue) > cap(queue)/2 {
+			return false
+		}
+	}
+	return true
+}
+
+// stopWorkers drops the queued messages of the partitions and waits for the
+// events in progress to finish.
+func (c *Consumer) stopWorkers(partitions []kafka.TopicPartition) {
+	stopped := make([]*partitionWorkers, 0, len(partitions))
+	for _, tp := range partitions {
+		key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+		w, ok := c.workers[key]
+		if !ok {
+			continue
+		}
+
+		close(w.revoked)
+		for _, queue := range w.queues {
+			close(queue)
+		}
+		delete(c.workers, key)
+		delete(c.throttled, key)
+		stopped = append(stopped, w)
+	}
+
+	for _, w := range stopped {
+		w.wg.Wait()
+	}
+}
+
+func (c *Consumer) assignedWorkers() []kafka.TopicPartition {
+	partitions := make([]kafka.TopicPartition, 0, len(c.workers))
+	for key := range c.workers {
+		topic := key.topic
+		partitions = append(partitions, kafka.TopicPartition{Topic: &topic, Partition: key.partition})
+	}
+	return partitions
+}
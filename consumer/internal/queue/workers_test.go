@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"consumer/internal/entity"
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingProcessor struct {
+	delay   time.Duration
+	mu      sync.Mutex
+	titles  map[string][]string
+	batches int
+}
+
+func (p *recordingProcessor) Process(_ context.Context, event entity.BookEvent) error {
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.titles[event.Book.Id] = append(p.titles[event.Book.Id], event.Book.Title)
+	return nil
+}
+
+func (p *recordingProcessor) ProcessBatch(_ context.Context, books []entity.Book) error {
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches++
+	for _, book := range books {
+		p.titles[book.Id] = append(p.titles[book.Id], book.Title)
+	}
+	return nil
+}
+
+func TestWorkers(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType bookv1.EventType
+		policy    WorkerPolicy
+		delay     time.Duration
+		batches   bool
+	}{
+		{
+			name:      "single events",
+			eventType: bookv1.EventType_EVENT_TYPE_UPDATED,
+			policy:    WorkerPolicy{Concurrency: 3, KeyWorkers: 4, QueueSize: 64, BatchSize: 8, BatchLinger: 10 * time.Millisecond},
+			delay:     time.Millisecond,
+		},
+		{
+			// workers are done before dispatch returns, the offsets must still move
+			name:      "instant workers",
+			eventType: bookv1.EventType_EVENT_TYPE_UPDATED,
+			policy:    WorkerPolicy{Concurrency: 3, KeyWorkers: 4, QueueSize: 64, BatchSize: 1},
+		},
+		{
+			name:      "batches",
+			eventType: bookv1.EventType_EVENT_TYPE_CREATED,
+			policy:    WorkerPolicy{Concurrency: 3, KeyWorkers: 1, QueueSize: 64, BatchSize: 8, BatchLinger: 10 * time.Millisecond},
+			delay:     time.Millisecond,
+			batches:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &recordingProcessor{delay: tt.delay, titles: make(map[string][]string)}
+			publisher := &recordingPublisher{}
+			c := &Consumer{
+				bookProcessor:   processor,
+				statusPublisher: publisher,
+				throttled:       make(map[partitionKey]struct{}),
+				workers:         make(map[partitionKey]*partitionWorkers),
+				workerPolicy:    tt.policy,
+				slots:           make(chan struct{}, tt.policy.Concurrency),
+				offsets:         newOffsetTracker(),
+				commitRequests:  make(chan struct{}, 1),
+			}
+
+			ids := []string{
+				"5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+				"0d6c3f4e-2b1a-4c5d-9e8f-7a6b5c4d3e2f",
+				"9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a",
+			}
+			topic := "books"
+			const count = 30
+			for i := 0; i < count; i++ {
+				value, err := proto.Marshal(&bookv1.BookEvent{
+					Id:    ids[i%len(ids)],
+					Title: strconv.Itoa(i),
+					Type:  tt.eventType,
+				})
+				if err != nil {
+					t.Fatalf("failed to marshal event: %s", err)
+				}
+
+				c.dispatch(&kafka.Message{
+					TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: kafka.Offset(i)},
+					Key:            []byte(ids[i%len(ids)]),
+					Value:          value,
+					Headers: []kafka.Header{
+						{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)},
+						{Key: SchemaVersionHeader, Value: []byte(BookSchemaVersion)},
+					},
+				})
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				offsets := c.offsets.Commitable()
+				if len(offsets) == 1 && offsets[0].Offset == count {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("expect all messages to be done, commitable %v", offsets)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			c.stopWorkers(c.assignedWorkers())
+
+			if len(c.workers) != 0 {
+				t.Errorf("expect workers to be stopped")
+			}
+			if len(publisher.statuses) != count {
+				t.Errorf("expect %d processing statuses, but got %d", count, len(publisher.statuses))
+			}
+			if tt.batches != (processor.batches > 0) {
+				t.Errorf("expect batches %t, but got %d", tt.batches, processor.batches)
+			}
+			for i, id := range ids {
+				titles := processor.titles[id]
+				if len(titles) != count/len(ids) {
+					t.Fatalf("expect %d events of %s, got %d", count/len(ids), id, len(titles))
+				}
+				for j, title := range titles {
+					if expect := strconv.Itoa(i + j*len(ids)); title != expect {
+						t.Errorf("expect events of %s in order, got %v", id, titles)
+						break
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package catalog
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type AuthorRepository interface {
+	ListAuthors(ctx context.Context, query storage.AuthorQuery) ([]entity.Author, error)
+	GetAuthor(ctx context.Context, id int64) (entity.AuthorStats, error)
+	ListBooks(ctx context.Context, query storage.BookQuery) ([]entity.Book, error)
+}
+
+type AuthorCatalogService struct {
+	authorRepository AuthorRepository
+}
+
+func NewAuthorCatalogService(repo AuthorRepository) *AuthorCatalogService {
+	return &AuthorCatalogService{
+		authorRepository: repo,
+	}
+}
+
+type ListAuthorsParams struct {
+	PageSize  int
+	PageToken string
+	Prefix    string
+}
+
+type AuthorPage struct {
+	Authors []entity.Author
+	// NextPageToken is empty on the last page.
+	NextPageToken string
+}
+
+// authorPageToken is opaque to clients, authors are always ordered by name.
+type authorPageToken struct {
+	Name string `json:"n"`
+}
+
+func (s *AuthorCatalogService) ListAuthors(ctx context.Context, params ListAuthorsParams) (AuthorPage, error) {
+	limit, err := pageSize(params.PageSize)
+	if err != nil {
+		return AuthorPage{}, err
+	}
+
+	// one more author tells whether there is a next page
+	query := storage.AuthorQuery{Prefix: params.Prefix, Limit: limit + 1}
+	if params.PageToken != "" {
+		var token authorPageToken
+		if err := decodePageToken(params.PageToken, &token); err != nil {
+			return AuthorPage{}, err
+		}
+		if token.Name == "" {
+			return AuthorPage{}, fmt.Errorf("%w: malformed page token", ErrInvalidArgument)
+		}
+		query.After = token.Name
+	}
+
+	authors, err := s.authorRepository.ListAuthors(ctx, query)
+	if err != nil {
+		slog.Error("failed to list authors", slog.String("error", err.Error()))
+		return AuthorPage{}, err
+	}
+
+	page := AuthorPage{Authors: authors}
+	if len(authors) == query.Limit {
+		page.Authors = authors[:len(authors)-1]
+		page.NextPageToken, err = encodePageToken(authorPageToken{Name: page.Authors[len(page.Authors)-1].Name})
+		if err != nil {
+			return AuthorPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+func (s *AuthorCatalogService) GetAuthor(ctx context.Context, id int64) (entity.AuthorStats, error) {
+	if id <= 0 {
+		return entity.AuthorStats{}, fmt.Errorf("%w: author id %d is not positive", ErrInvalidArgument, id)
+	}
+
+	stats, err := s.authorRepository.GetAuthor(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrAuthorNotFound) {
+			slog.Error("failed to get author", slog.Int64("id", id), slog.String("error", err.Error()))
+		}
+		return entity.AuthorStats{}, err
+	}
+
+	return stats, nil
+}
+
+// ListAuthorBooks returns a page of the author's books. Only an empty page
+// checks whether the author exists at all.
+func (s *AuthorCatalogService) ListAuthorBooks(ctx context.Context, id int64, params ListBooksParams) (BookPage, error) {
+	if id <= 0 {
+		return BookPage{}, fmt.Errorf("%w: author id %d is not positive", ErrInvalidArgument, id)
+	}
+
+	query, err := bookQuery(params)
+	if err != nil {
+		return BookPage{}, err
+	}
+	query.AuthorId = id
+
+	page, err := listBooks(ctx, s.authorRepository, query)
+	if err != nil || len(page.Books) > 0 {
+		return page, err
+	}
+
+	if _, err := s.GetAuthor(ctx, id); err != nil {
+		return BookPage{}, err
+	}
+	return page, nil
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// memoryAuthors keeps authors sorted by name, every author has no books.
+type memoryAuthors struct {
+	memoryBooks
+	authors []entity.Author
+}
+
+func (m *memoryAuthors) ListAuthors(_ context.Context, query storage.AuthorQuery) ([]entity.Author, error) {
+	var authors []entity.Author
+	for _, author := range m.authors {
+		if author.Name <= query.After || !strings.HasPrefix(strings.ToLower(author.Name), strings.ToLower(query.Prefix)) {
+			continue
+		}
+		if len(authors) == query.Limit {
+			break
+		}
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+func (m *memoryAuthors) GetAuthor(_ context.Context, id int64) (entity.AuthorStats, error) {
+	for _, author := range m.authors {
+		if author.Id == id {
+			return entity.AuthorStats{Author: author}, nil
+		}
+	}
+	return entity.AuthorStats{}, storage.ErrAuthorNotFound
+}
+
+func newMemoryAuthors() *memoryAuthors {
+	return &memoryAuthors{authors: []entity.Author{
+		{Id: 3, Name: "Anna Akhmatova"},
+		{Id: 1, Name: "Anton Chekhov"},
+		{Id: 4, Name: "Fyodor Dostoevsky"},
+		{Id: 2, Name: "anonymous"},
+	}}
+}
+
+func TestListAuthors(t *testing.T) {
+	service := NewAuthorCatalogService(newMemoryAuthors())
+
+	var (
+		names []string
+		token string
+	)
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatalf("expect the pages to end")
+		}
+
+		page, err := service.ListAuthors(context.Background(), ListAuthorsParams{PageSize: 1, PageToken: token, Prefix: "AN"})
+		if err != nil {
+			t.Fatalf("failed to list authors: %s", err)
+		}
+		for _, author := range page.Authors {
+			names = append(names, author.Name)
+		}
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+
+	if expect := []string{"Anna Akhmatova", "Anton Chekhov", "anonymous"}; !slices.Equal(names, expect) {
+		t.Errorf("expect authors %v, but got %v", expect, names)
+	}
+
+	if _, err := service.ListAuthors(context.Background(), ListAuthorsParams{PageToken: "e30"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expect invalid argument for a token without name, but got %v", err)
+	}
+}
+
+func TestListAuthorBooks(t *testing.T) {
+	repo := newMemoryAuthors()
+	service := NewAuthorCatalogService(repo)
+
+	page, err := service.ListAuthorBooks(context.Background(), 1, ListBooksParams{})
+	if err != nil || len(page.Books) != 0 {
+		t.Errorf("expect an empty page, but got %+v, %v", page, err)
+	}
+	if len(repo.queries) != 1 || repo.queries[0].AuthorId != 1 {
+		t.Errorf("expect books of the author to be queried, got %+v", repo.queries)
+	}
+
+	if _, err := service.ListAuthorBooks(context.Background(), 42, ListBooksParams{}); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("expect not found, but got %v", err)
+	}
+
+	if _, err := service.ListAuthorBooks(context.Background(), 0, ListBooksParams{}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expect invalid argument, but got %v", err)
+	}
+}
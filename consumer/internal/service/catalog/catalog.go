@@ -0,0 +1,189 @@
+package catalog
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+type BookRepository interface {
+	GetBook(ctx context.Context, id string) (entity.Book, error)
+	ListBooks(ctx context.Context, query storage.BookQuery) ([]entity.Book, error)
+	SearchBooks(ctx context.Context, query storage.SearchQuery) ([]entity.BookMatch, error)
+}
+
+var ErrInvalidArgument = errors.New("invalid argument")
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type BookCatalogService struct {
+	bookRepository BookRepository
+}
+
+func NewBookCatalogService(repo BookRepository) *BookCatalogService {
+	return &BookCatalogService{
+		bookRepository: repo,
+	}
+}
+
+func (s *BookCatalogService) GetBook(ctx context.Context, id string) (entity.Book, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entity.Book{}, fmt.Errorf("%w: book id %q is not a uuid", ErrInvalidArgument, id)
+	}
+
+	book, err := s.bookRepository.GetBook(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrBookNotFound) {
+			slog.Error("failed to get book", slog.String("id", id), slog.String("error", err.Error()))
+		}
+		return entity.Book{}, err
+	}
+
+	return book, nil
+}
+
+type ListBooksParams struct {
+	PageSize   int
+	PageToken  string
+	OrderBy    storage.BookOrder
+	Descending bool
+	Author     string
+	Title      string
+}
+
+type BookPage struct {
+	Books []entity.Book
+	// NextPageToken is empty on the last page.
+	NextPageToken string
+}
+
+func (s *BookCatalogService) ListBooks(ctx context.Context, params ListBooksParams) (BookPage, error) {
+	query, err := bookQuery(params)
+	if err != nil {
+		return BookPage{}, err
+	}
+
+	return listBooks(ctx, s.bookRepository, query)
+}
+
+type bookLister interface {
+	ListBooks(ctx context.Context, query storage.BookQuery) ([]entity.Book, error)
+}
+
+func listBooks(ctx context.Context, repo bookLister, query storage.BookQuery) (BookPage, error) {
+	// one more book tells whether there is a next page
+	query.Limit++
+	books, err := repo.ListBooks(ctx, query)
+	if err != nil {
+		slog.Error("failed to list books", slog.String("error", err.Error()))
+		return BookPage{}, err
+	}
+
+	page := BookPage{Books: books}
+	if len(books) == query.Limit {
+		page.Books = books[:len(books)-1]
+		last := page.Books[len(page.Books)-1]
+		page.NextPageToken, err = encodePageToken(bookPageToken{
+			OrderBy:    query.OrderBy,
+			Descending: query.Descending,
+			Id:         last.Id,
+			Title:      last.Title,
+		})
+		if err != nil {
+			return BookPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+func pageSize(size int) (int, error) {
+	switch {
+	case size < 0:
+		return 0, fmt.Errorf("%w: page size %d is negative", ErrInvalidArgument, size)
+	case size == 0:
+		return defaultPageSize, nil
+	default:
+		return min(size, maxPageSize), nil
+	}
+}
+
+func bookQuery(params ListBooksParams) (storage.BookQuery, error) {
+	limit, err := pageSize(params.PageSize)
+	if err != nil {
+		return storage.BookQuery{}, err
+	}
+
+	query := storage.BookQuery{
+		Author:     params.Author,
+		Title:      params.Title,
+		OrderBy:    params.OrderBy,
+		Descending: params.Descending,
+		Limit:      limit,
+	}
+
+	switch query.OrderBy {
+	case "":
+		query.OrderBy = storage.OrderById
+	case storage.OrderById, storage.OrderByTitle:
+	default:
+		return storage.BookQuery{}, fmt.Errorf("%w: unknown order %q", ErrInvalidArgument, query.OrderBy)
+	}
+
+	if params.PageToken == "" {
+		return query, nil
+	}
+
+	var token bookPageToken
+	if err := decodePageToken(params.PageToken, &token); err != nil {
+		return storage.BookQuery{}, err
+	}
+	if token.OrderBy != query.OrderBy || token.Descending != query.Descending {
+		return storage.BookQuery{}, fmt.Errorf("%w: page token is issued for another order", ErrInvalidArgument)
+	}
+	id, err := uuid.Parse(token.Id)
+	if err != nil {
+		return storage.BookQuery{}, fmt.Errorf("%w: malformed page token", ErrInvalidArgument)
+	}
+	query.After = &storage.BookCursor{Id: id, Title: token.Title}
+
+	return query, nil
+}
+
+// bookPageToken is opaque to clients. It keeps the order it was issued for, a
+// cursor means nothing in another one.
+type bookPageToken struct {
+	OrderBy    storage.BookOrder `json:"o"`
+	Descending bool              `json:"d,omitempty"`
+	Id         string            `json:"i"`
+	Title      string            `json:"t,omitempty"`
+}
+
+func encodePageToken(token any) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(s string, token any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("%w: malformed page token", ErrInvalidArgument)
+	}
+
+	if err := json.Unmarshal(data, token); err != nil {
+		return fmt.Errorf("%w: malformed page token", ErrInvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package catalog
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// memoryBooks lists books ordered by id, the only order it knows.
+type memoryBooks struct {
+	books   []entity.Book
+	queries []storage.BookQuery
+}
+
+func (m *memoryBooks) GetBook(_ context.Context, id string) (entity.Book, error) {
+	for _, book := range m.books {
+		if book.Id == id {
+			return book, nil
+		}
+	}
+	return entity.Book{}, storage.ErrBookNotFound
+}
+
+func (m *memoryBooks) ListBooks(_ context.Context, query storage.BookQuery) ([]entity.Book, error) {
+	m.queries = append(m.queries, query)
+
+	var books []entity.Book
+	for _, book := range m.books {
+		if query.After != nil && book.Id <= query.After.Id.String() {
+			continue
+		}
+		if len(books) == query.Limit {
+			break
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// SearchBooks finds books by title, all of them ranked the same.
+func (m *memoryBooks) SearchBooks(_ context.Context, query storage.SearchQuery) ([]entity.BookMatch, error) {
+	if query.Language == "klingon" {
+		return nil, storage.ErrUnknownLanguage
+	}
+
+	var matches []entity.BookMatch
+	for _, book := range slices.Backward(m.books) {
+		if !strings.Contains(book.Title, query.Query) {
+			continue
+		}
+		if query.After != nil && book.Id >= query.After.Id.String() {
+			continue
+		}
+		if len(matches) == query.Limit {
+			break
+		}
+		matches = append(matches, entity.BookMatch{Book: book, Rank: 0.5})
+	}
+	return matches, nil
+}
+
+var ids = []string{
+	"0d6c3f4e-2b1a-4c5d-9e8f-7a6b5c4d3e2f",
+	"5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+	"7c1e2d3f-4a5b-4c6d-8e9f-0a1b2c3d4e5f",
+	"9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a",
+	"e3b0c442-98fc-4c14-9afb-f4c8996fb924",
+}
+
+func newMemoryBooks() *memoryBooks {
+	repo := &memoryBooks{}
+	for _, id := range ids {
+		repo.books = append(repo.books, entity.Book{Id: id, Title: "title " + id[:4]})
+	}
+	return repo
+}
+
+func TestListBooksPages(t *testing.T) {
+	repo := newMemoryBooks()
+	service := NewBookCatalogService(repo)
+
+	var (
+		listed []string
+		token  string
+		pages  int
+	)
+	for {
+		page, err := service.ListBooks(context.Background(), ListBooksParams{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("failed to list books: %s", err)
+		}
+		pages++
+		for _, book := range page.Books {
+			listed = append(listed, book.Id)
+		}
+
+		token = page.NextPageToken
+		if token == "" {
+			break
+		}
+		if pages > len(ids) {
+			t.Fatalf("expect the pages to end")
+		}
+	}
+
+	if pages != 3 {
+		t.Errorf("expect 3 pages, but got %d", pages)
+	}
+	if !slices.Equal(listed, ids) {
+		t.Errorf("expect every book once in order, got %v", listed)
+	}
+	for _, query := range repo.queries {
+		if query.Limit != 3 || query.OrderBy != storage.OrderById {
+			t.Errorf("unexpected query: %+v", query)
+		}
+	}
+}
+
+func TestListBooksParams(t *testing.T) {
+	token, err := encodePageToken(bookPageToken{OrderBy: storage.OrderByTitle, Id: ids[0], Title: "title"})
+	if err != nil {
+		t.Fatalf("failed to encode page token: %s", err)
+	}
+
+	tests := []struct {
+		name        string
+		params      ListBooksParams
+		expectLimit int
+		expectErr   error
+	}{
+		{name: "default page size", params: ListBooksParams{}, expectLimit: defaultPageSize + 1},
+		{name: "page size over max", params: ListBooksParams{PageSize: 1000}, expectLimit: maxPageSize + 1},
+		{name: "negative page size", params: ListBooksParams{PageSize: -1}, expectErr: ErrInvalidArgument},
+		{name: "unknown order", params: ListBooksParams{OrderBy: "year"}, expectErr: ErrInvalidArgument},
+		{name: "malformed token", params: ListBooksParams{PageToken: "not a token"}, expectErr: ErrInvalidArgument},
+		{name: "token of another order", params: ListBooksParams{PageToken: token}, expectErr: ErrInvalidArgument},
+		{
+			name:        "token of the same order",
+			params:      ListBooksParams{PageToken: token, OrderBy: storage.OrderByTitle},
+			expectLimit: defaultPageSize + 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryBooks()
+			_, err := NewBookCatalogService(repo).ListBooks(context.Background(), tt.params)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expect error %v, but got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to list books: %s", err)
+			}
+			if repo.queries[0].Limit != tt.expectLimit {
+				t.Errorf("expect limit %d, but got %d", tt.expectLimit, repo.queries[0].Limit)
+			}
+		})
+	}
+}
+
+func TestGetBook(t *testing.T) {
+	service := NewBookCatalogService(newMemoryBooks())
+
+	book, err := service.GetBook(context.Background(), ids[1])
+	if err != nil || book.Id != ids[1] {
+		t.Errorf("expect book %s, but got %+v, %v", ids[1], book, err)
+	}
+
+	_, err = service.GetBook(context.Background(), strings.Replace(ids[1], "5", "4", 1))
+	if !errors.Is(err, storage.ErrBookNotFound) {
+		t.Errorf("expect not found, but got %v", err)
+	}
+
+	_, err = service.GetBook(context.Background(), "42")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expect invalid argument, but got %v", err)
+	}
+}
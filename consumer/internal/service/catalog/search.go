@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+)
+
+type SearchBooksParams struct {
+	Query     string
+	Language  string
+	PageSize  int
+	PageToken string
+}
+
+type SearchPage struct {
+	Matches []entity.BookMatch
+	// NextPageToken is empty on the last page.
+	NextPageToken string
+}
+
+// searchPageToken is opaque to clients. It keeps a hash of the query it was
+// issued for, a rank means nothing for another one.
+type searchPageToken struct {
+	Query uint64  `json:"q"`
+	Rank  float32 `json:"r"`
+	Id    string  `json:"i"`
+}
+
+func (s *BookCatalogService) SearchBooks(ctx context.Context, params SearchBooksParams) (SearchPage, error) {
+	if strings.TrimSpace(params.Query) == "" {
+		return SearchPage{}, fmt.Errorf("%w: search query is empty", ErrInvalidArgument)
+	}
+
+	limit, err := pageSize(params.PageSize)
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	// one more book tells whether there is a next page
+	query := storage.SearchQuery{Query: params.Query, Language: params.Language, Limit: limit + 1}
+	fingerprint := searchFingerprint(params)
+	if params.PageToken != "" {
+		var token searchPageToken
+		if err := decodePageToken(params.PageToken, &token); err != nil {
+			return SearchPage{}, err
+		}
+		if token.Query != fingerprint {
+			return SearchPage{}, fmt.Errorf("%w: page token is issued for another query", ErrInvalidArgument)
+		}
+		id, err := uuid.Parse(token.Id)
+		if err != nil {
+			return SearchPage{}, fmt.Errorf("%w: malformed page token", ErrInvalidArgument)
+		}
+		query.After = &storage.SearchCursor{Rank: token.Rank, Id: id}
+	}
+
+	matches, err := s.bookRepository.SearchBooks(ctx, query)
+	if errors.Is(err, storage.ErrUnknownLanguage) {
+		return SearchPage{}, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+	if err != nil {
+		slog.Error("failed to search books", slog.String("error", err.Error()))
+		return SearchPage{}, err
+	}
+
+	page := SearchPage{Matches: matches}
+	if len(matches) == query.Limit {
+		page.Matches = matches[:len(matches)-1]
+		last := page.Matches[len(page.Matches)-1]
+		page.NextPageToken, err = encodePageToken(searchPageToken{
+			Query: fingerprint,
+			Rank:  last.Rank,
+			Id:    last.Book.Id,
+		})
+		if err != nil {
+			return SearchPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+func searchFingerprint(params SearchBooksParams) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(params.Language))
+	h.Write([]byte{0})
+	h.Write([]byte(params.Query))
+	return h.Sum64()
+}
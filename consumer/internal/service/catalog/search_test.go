@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSearchBooks(t *testing.T) {
+	service := NewBookCatalogService(newMemoryBooks())
+
+	var (
+		found []string
+		token string
+	)
+	for pages := 0; ; pages++ {
+		if pages > len(ids) {
+			t.Fatalf("expect the pages to end")
+		}
+
+		page, err := service.SearchBooks(context.Background(), SearchBooksParams{Query: "title", PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("failed to search books: %s", err)
+		}
+		for _, match := range page.Matches {
+			found = append(found, match.Book.Id)
+		}
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+
+	expect := slices.Clone(ids)
+	slices.Reverse(expect)
+	if !slices.Equal(found, expect) {
+		t.Errorf("expect every book once by rank, got %v", found)
+	}
+}
+
+func TestSearchBooksParams(t *testing.T) {
+	service := NewBookCatalogService(newMemoryBooks())
+
+	page, err := service.SearchBooks(context.Background(), SearchBooksParams{Query: "title", PageSize: 1})
+	if err != nil {
+		t.Fatalf("failed to search books: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		params SearchBooksParams
+	}{
+		{name: "empty query", params: SearchBooksParams{Query: "  "}},
+		{name: "negative page size", params: SearchBooksParams{Query: "title", PageSize: -1}},
+		{name: "unknown language", params: SearchBooksParams{Query: "title", Language: "klingon"}},
+		{name: "token of another query", params: SearchBooksParams{Query: "book", PageToken: page.NextPageToken}},
+		{name: "token of another language", params: SearchBooksParams{Query: "title", Language: "simple", PageToken: page.NextPageToken}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SearchBooks(context.Background(), tt.params)
+			if !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("expect invalid argument, but got %v", err)
+			}
+		})
+	}
+}
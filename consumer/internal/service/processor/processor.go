@@ -2,7 +2,13 @@ package processor
 
 import (
 	"consumer/internal/entity"
+	"consumer/internal/metrics"
+	"consumer/internal/storage"
 	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"log/slog"
 	"strings"
 	"time"
@@ -10,19 +16,41 @@ import (
 
 type BookRepository interface {
 	SaveBook(ctx context.Context, b entity.Book) error
+	SaveBooks(ctx context.Context, books []entity.Book) error
+	UpdateBook(ctx context.Context, b entity.Book, fields []string) error
+	DeleteBook(ctx context.Context, b entity.Book) error
+}
+
+type BookStatusPublisher interface {
+	Publish(status entity.BookStatus) error
 }
 
 type BookProcessorService struct {
-	bookRepository BookRepository
+	bookRepository  BookRepository
+	statusPublisher BookStatusPublisher
 }
 
-func NewBookProcessorService(repo BookRepository) *BookProcessorService {
+func NewBookProcessorService(repo BookRepository, statusPublisher BookStatusPublisher) *BookProcessorService {
 	return &BookProcessorService{
-		bookRepository: repo,
+		bookRepository:  repo,
+		statusPublisher: statusPublisher,
 	}
 }
 
-func (s *BookProcessorService) Process(ctx context.Context, book entity.Book) error {
+// Process applies the event and publishes its final status once it succeeds.
+// Processing and failed statuses are published by the caller, which knows
+// whether the event is on its first attempt or dead-lettered.
+func (s *BookProcessorService) Process(ctx context.Context, event entity.BookEvent) error {
+	start := time.Now()
+	book := event.Book
+
+	ctx, span := otel.Tracer("consumer/internal/service/processor").Start(ctx, "Process")
+	span.SetAttributes(
+		attribute.String("book.id", book.Id),
+		attribute.String("book.event", string(event.Type)),
+	)
+	defer span.End()
+
 	// some super complicated processing
 	book.Text = strings.ToUpper(book.Text)
 
@@ -30,11 +58,93 @@ func (s *BookProcessorService) Process(ctx context.Context, book entity.Book) er
 	ctx, cancel := context.WithTimeout(ctx, timeoutToSave)
 	defer cancel()
 
-	if err := s.bookRepository.SaveBook(ctx, book); err != nil {
-		slog.Error("failed to save book", slog.String("id", book.Id), slog.String("error", err.Error()))
+	var (
+		err    error
+		status = entity.StatusSaved
+	)
+	switch event.Type {
+	case entity.EventCreated:
+		err = s.bookRepository.SaveBook(ctx, book)
+	case entity.EventUpdated:
+		err = s.bookRepository.UpdateBook(ctx, book, event.Fields)
+	case entity.EventDeleted:
+		err = s.bookRepository.DeleteBook(ctx, book)
+		status = entity.StatusDeleted
+	default:
+		err = fmt.Errorf("unknown event type %q", event.Type)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.ProcessingDuration.WithLabelValues(string(event.Type), metrics.ResultFailure).Observe(time.Since(start).Seconds())
+		metrics.SaveFailures.WithLabelValues(string(event.Type), storage.ErrorClass(err)).Inc()
+		slog.Error("failed to apply book event",
+			slog.String("id", book.Id),
+			slog.String("type", string(event.Type)),
+			slog.String("error", err.Error()))
 		return err
 	}
-	slog.Info("book is saved", slog.String("id", book.Id), slog.String("text", book.Text))
+	metrics.ProcessingDuration.WithLabelValues(string(event.Type), metrics.ResultSuccess).Observe(time.Since(start).Seconds())
+	slog.Info("book event is applied", slog.String("id", book.Id), slog.String("type", string(event.Type)))
+	s.publishStatus(book.Id, status, "")
 
 	return nil
 }
+
+// ProcessBatch saves new books in one go. The caller is expected to fall back
+// to Process for every book of a failed batch.
+func (s *BookProcessorService) ProcessBatch(ctx context.Context, books []entity.Book) error {
+	start := time.Now()
+
+	ctx, span := otel.Tracer("consumer/internal/service/processor").Start(ctx, "ProcessBatch")
+	span.SetAttributes(attribute.Int("batch.size", len(books)))
+	defer span.End()
+
+	batch := make([]entity.Book, len(books))
+	for i, book := range books {
+		// some super complicated processing
+		book.Text = strings.ToUpper(book.Text)
+		batch[i] = book
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, batchTimeoutToSave)
+	defer cancel()
+
+	metrics.BatchSize.Observe(float64(len(batch)))
+	if err := s.bookRepository.SaveBooks(ctx, batch); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.ProcessingDuration.WithLabelValues(batchType, metrics.ResultFailure).Observe(time.Since(start).Seconds())
+		metrics.SaveFailures.WithLabelValues(batchType, storage.ErrorClass(err)).Inc()
+		slog.Error("failed to save book batch", slog.Int("size", len(batch)), slog.String("error", err.Error()))
+		return err
+	}
+	metrics.ProcessingDuration.WithLabelValues(batchType, metrics.ResultSuccess).Observe(time.Since(start).Seconds())
+	slog.Info("book batch is saved", slog.Int("size", len(batch)))
+	for _, book := range batch {
+		s.publishStatus(book.Id, entity.StatusSaved, "")
+	}
+
+	return nil
+}
+
+const (
+	batchTimeoutToSave = 10 * time.Second
+	// batchType labels batch metrics next to the event types
+	batchType = "created_batch"
+)
+
+func (s *BookProcessorService) publishStatus(id string, status entity.Status, reason string) {
+	err := s.statusPublisher.Publish(entity.BookStatus{
+		Id:        id,
+		Status:    status,
+		Reason:    reason,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Error("failed to publish book status",
+			slog.String("id", id),
+			slog.String("status", string(status)),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,67 @@
+package postgresql
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+func (s *BookStorage) ListAuthors(ctx context.Context, q storage.AuthorQuery) ([]entity.Author, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.After != "" {
+		where = append(where, "a.name > "+arg(q.After))
+	}
+	if q.Prefix != "" {
+		where = append(where, "lower(a.name) LIKE lower("+arg(likeEscaper.Replace(q.Prefix))+") || '%'")
+	}
+
+	sql := "SELECT a.id, a.name FROM authors a"
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	sql += " ORDER BY a.name LIMIT " + arg(q.Limit)
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors: %w", err)
+	}
+
+	authors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[entity.Author])
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors: %w", err)
+	}
+
+	return authors, nil
+}
+
+func (s *BookStorage) GetAuthor(ctx context.Context, id int64) (entity.AuthorStats, error) {
+	var stats entity.AuthorStats
+	err := s.pool.QueryRow(ctx,
+		`SELECT a.id, a.name, COUNT(b.id), COALESCE(SUM(length(b.text)), 0)
+		FROM authors a
+		LEFT JOIN book_authors ba ON ba.author_id = a.id
+		LEFT JOIN books b ON b.id = ba.book_id
+		WHERE a.id = $1
+		GROUP BY a.id`, id).
+		Scan(&stats.Author.Id, &stats.Author.Name, &stats.CountBooks, &stats.CountTextSymbols)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.AuthorStats{}, storage.ErrAuthorNotFound
+	}
+	if err != nil {
+		return entity.AuthorStats{}, fmt.Errorf("failed to query author: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+// bookAuthors selects the sorted author names of the book b.
+const bookAuthors = `ARRAY(SELECT a.name FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+	WHERE ba.book_id = b.id ORDER BY a.name)`
+
+func (s *BookStorage) GetBook(ctx context.Context, id string) (entity.Book, error) {
+	bookId, err := uuid.Parse(id)
+	if err != nil {
+		return entity.Book{}, fmt.Errorf("invalid book id: %w", err)
+	}
+
+	var book storage.BookRow
+	err = s.pool.QueryRow(ctx,
+		`SELECT b.id, b.title, COALESCE(b.text, ''), b.version, `+bookAuthors+`
+		FROM books b WHERE b.id = $1`, bookId).
+		Scan(&book.Id, &book.Title, &book.Text, &book.Version, &book.Authors)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Book{}, storage.ErrBookNotFound
+	}
+	if err != nil {
+		return entity.Book{}, fmt.Errorf("failed to query book: %w", err)
+	}
+
+	return storage.ToModel(book), nil
+}
+
+// likeEscaper makes user input match literally inside a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListBooks returns a page of books without their text. Pages are read by
+// keyset, so a page costs the same however deep it is.
+func (s *BookStorage) ListBooks(ctx context.Context, q storage.BookQuery) ([]entity.Book, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// the id ends every order, so the sort key of a book is unique
+	var columns, after []string
+	switch q.OrderBy {
+	case storage.OrderById:
+		columns = []string{"b.id"}
+		if q.After != nil {
+			after = []string{arg(q.After.Id)}
+		}
+	case storage.OrderByTitle:
+		columns = []string{"b.title", "b.id"}
+		if q.After != nil {
+			after = []string{arg(q.After.Title), arg(q.After.Id)}
+		}
+	default:
+		return nil, fmt.Errorf("unknown book order %q", q.OrderBy)
+	}
+
+	direction, cmp := "ASC", ">"
+	if q.Descending {
+		direction, cmp = "DESC", "<"
+	}
+	if after != nil {
+		where = append(where, fmt.Sprintf("(%s) %s (%s)",
+			strings.Join(columns, ", "), cmp, strings.Join(after, ", ")))
+	}
+	if q.Author != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+			WHERE ba.book_id = b.id AND a.name = `+arg(q.Author)+`)`)
+	}
+	if q.AuthorId != 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = "+arg(q.AuthorId)+")")
+	}
+	if q.Title != "" {
+		where = append(where, "b.title ILIKE '%' || "+arg(likeEscaper.Replace(q.Title))+" || '%'")
+	}
+
+	sql := "SELECT b.id, b.title, b.version, " + bookAuthors + " FROM books b"
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		order[i] = column + " " + direction
+	}
+	sql += " ORDER BY " + strings.Join(order, ", ") + " LIMIT " + arg(q.Limit)
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books: %w", err)
+	}
+
+	books, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Book, error) {
+		var book storage.BookRow
+		err := row.Scan(&book.Id, &book.Title, &book.Version, &book.Authors)
+		return storage.ToModel(book), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books: %w", err)
+	}
+
+	return books, nil
+}
@@ -6,30 +6,48 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
+	"slices"
 	"strings"
 )
 
+// BookStorage indexes books for text search in language, a Postgres text
+// search configuration such as english or russian.
 type BookStorage struct {
-	pool *pgxpool.Pool
+	pool     *pgxpool.Pool
+	language string
 }
 
-func NewStorage(ctx context.Context, connStr string) (*BookStorage, error) {
-	pool, err := pgxpool.New(ctx, connStr)
+func NewStorage(ctx context.Context, connStr string, language string) (*BookStorage, error) {
+	config, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	config.ConnConfig.Tracer = newQueryTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BookStorage{
-		pool: pool,
+		pool:     pool,
+		language: language,
 	}, nil
 }
 
-func (s *BookStorage) SaveBook(ctx context.Context, b entity.Book) error {
-	book := storage.FromModel(b)
+func (s *BookStorage) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
 
+func (s *BookStorage) Stat() *pgxpool.Stat {
+	return s.pool.Stat()
+}
+
+func (s *BookStorage) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -39,48 +57,325 @@ func (s *BookStorage) SaveBook(ctx context.Context, b entity.Book) error {
 			if errors.Is(err, pgx.ErrTxClosed) {
 				return
 			}
-			
+
 			slog.Error("failed to rollback transaction", slog.String("error", err.Error()))
 		}
 	}()
 
-	_, err = tx.Exec(ctx,
-		"INSERT INTO books (id, title, text) VALUES ($1, $2, $3)",
-		book.Id, book.Title, book.Text)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *BookStorage) SaveBook(ctx context.Context, b entity.Book) error {
+	return s.SaveBooks(ctx, []entity.Book{b})
+}
+
+// SaveBooks writes whole books with their authors in one transaction. The
+// number of statements does not depend on the number of books, ids must be
+// unique.
+func (s *BookStorage) SaveBooks(ctx context.Context, books []entity.Book) error {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		return s.saveBooks(ctx, tx, books)
+	})
+}
+
+// saveBooks upserts whole books. A book stored or deleted with the same or a
+// newer version is left as is, so a redelivered event is a no-op and a late
+// event does not bring a deleted book back, while a book without a version
+// always overwrites the stored one. Authors are replaced only for the books
+// actually written.
+func (s *BookStorage) saveBooks(ctx context.Context, tx pgx.Tx, books []entity.Book) error {
+	var (
+		ids      = make([]uuid.UUID, 0, len(books))
+		titles   = make([]string, 0, len(books))
+		texts    = make([]string, 0, len(books))
+		versions = make([]int64, 0, len(books))
+		authors  = make(map[uuid.UUID][]string, len(books))
+	)
+	for _, b := range books {
+		book := storage.FromModel(b)
+		ids = append(ids, book.Id)
+		titles = append(titles, book.Title)
+		texts = append(texts, book.Text)
+		versions = append(versions, book.Version)
+		authors[book.Id] = book.Authors
+	}
+
+	// rows are locked in id order, so concurrent batches do not deadlock
+	rows, err := tx.Query(ctx,
+		`INSERT INTO books (id, title, text, version, language)
+		SELECT b.*, $5::regconfig FROM unnest($1::uuid[], $2::text[], $3::text[], $4::bigint[]) AS b (id, title, text, version)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM deleted_books d WHERE d.id = b.id AND b.version <> 0 AND d.version >= b.version
+		)
+		ORDER BY 1
+		ON CONFLICT (id) DO UPDATE
+		SET title = EXCLUDED.title, text = EXCLUDED.text, version = GREATEST(books.version, EXCLUDED.version),
+			language = EXCLUDED.language
+		WHERE EXCLUDED.version = 0 OR books.version < EXCLUDED.version
+		RETURNING id`,
+		ids, titles, texts, versions, s.language)
+	if err != nil {
+		return fmt.Errorf("failed to upsert books: %w", err)
+	}
+	written, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return fmt.Errorf("failed to upsert books: %w", err)
+	}
+	if skipped := len(books) - len(written); skipped > 0 {
+		slog.Debug("books are already saved or deleted", slog.Int("skipped", skipped))
+	}
+
+	// a book written again is newer than its tombstone
+	_, err = tx.Exec(ctx, "DELETE FROM deleted_books WHERE id = ANY($1::uuid[])", written)
 	if err != nil {
-		return fmt.Errorf("failed to insert book: %w", err)
+		return fmt.Errorf("failed to delete book tombstones: %w", err)
 	}
 
-	for _, authorName := range book.Authors {
-		authorName = strings.TrimSpace(authorName)
-		if authorName == "" {
-			continue
+	var links authorLinks
+	for _, id := range written {
+		links.add(id, authors[id])
+	}
+	return replaceAuthors(ctx, tx, written, links)
+}
+
+// authorLinks pairs books with author names, one pair per index.
+type authorLinks struct {
+	books []uuid.UUID
+	names []string
+}
+
+func (l *authorLinks) add(bookId uuid.UUID, authors []string) {
+	for _, name := range authorNames(authors) {
+		l.books = append(l.books, bookId)
+		l.names = append(l.names, name)
+	}
+}
+
+// authorNames trims the names and drops empty and repeated ones.
+func authorNames(authors []string) []string {
+	names := make([]string, 0, len(authors))
+	for _, name := range authors {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
 		}
+	}
+	return names
+}
 
-		var authorID int64
+// bookColumns lists the fields a partial update may change directly in books.
+var bookColumns = map[string]string{
+	entity.FieldTitle: "title",
+	entity.FieldText:  "text",
+}
 
-		err = tx.QueryRow(ctx,
-			"SELECT id FROM authors WHERE name = $1", authorName).Scan(&authorID)
+// UpdateBook replaces the whole book when fields is empty, creating it if it
+// does not exist. Otherwise only the given fields of an existing book change.
+// Updates not newer than the stored version are skipped.
+func (s *BookStorage) UpdateBook(ctx context.Context, b entity.Book, fields []string) error {
+	book := storage.FromModel(b)
+
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		if len(fields) == 0 {
+			return s.saveBooks(ctx, tx, []entity.Book{b})
+		}
+
+		var version int64
+		err := tx.QueryRow(ctx,
+			"SELECT version FROM books WHERE id = $1 FOR UPDATE", book.Id).Scan(&version)
 		if errors.Is(err, pgx.ErrNoRows) {
-			err = tx.QueryRow(ctx,
-				"INSERT INTO authors (name) VALUES ($1) RETURNING id",
-				authorName).Scan(&authorID)
-			if err != nil {
-				return fmt.Errorf("failed to insert author %s: %w", authorName, err)
+			return bookNotFound(ctx, tx, book)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query book: %w", err)
+		}
+		if book.Version != 0 && book.Version <= version {
+			slog.Debug("book update is already applied", slog.String("id", b.Id))
+			return nil
+		}
+
+		var (
+			sets        = []string{"version = GREATEST(version, $2)"}
+			args        = []any{book.Id, book.Version}
+			withAuthors bool
+		)
+		for _, field := range fields {
+			if field == entity.FieldAuthors {
+				withAuthors = true
+				continue
 			}
-		} else if err != nil {
-			return fmt.Errorf("failed to query author %s: %w", authorName, err)
+
+			column, ok := bookColumns[field]
+			if !ok {
+				return fmt.Errorf("unknown book field %q", field)
+			}
+
+			switch field {
+			case entity.FieldTitle:
+				args = append(args, book.Title)
+			case entity.FieldText:
+				args = append(args, book.Text)
+			}
+			sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
 		}
 
 		_, err = tx.Exec(ctx,
-			"INSERT INTO book_authors (book_id, author_id) VALUES ($1, $2)",
-			book.Id, authorID)
+			"UPDATE books SET "+strings.Join(sets, ", ")+" WHERE id = $1", args...)
 		if err != nil {
-			return fmt.Errorf("failed to link book with author %s: %w", authorName, err)
+			return fmt.Errorf("failed to update book: %w", err)
 		}
+
+		if withAuthors {
+			var links authorLinks
+			links.add(book.Id, book.Authors)
+			return replaceAuthors(ctx, tx, []uuid.UUID{book.Id}, links)
+		}
+
+		return nil
+	})
+}
+
+// bookNotFound tells a book that is not created yet from a deleted one. An
+// update not newer than the delete is stale and skipped.
+func bookNotFound(ctx context.Context, tx pgx.Tx, book storage.BookRow) error {
+	var deleted int64
+	err := tx.QueryRow(ctx,
+		"SELECT version FROM deleted_books WHERE id = $1", book.Id).Scan(&deleted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrBookNotCreated
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query book tombstone: %w", err)
 	}
+	if book.Version != 0 && book.Version <= deleted {
+		slog.Debug("book update is older than its delete", slog.String("id", book.Id.String()))
+		return nil
+	}
+	return storage.ErrBookNotFound
+}
 
-	return tx.Commit(ctx)
+// DeleteBook is a no-op for a missing book, so a redelivered event does not
+// fail. A book stored with a newer version is kept. The delete leaves a
+// tombstone with its version, older events of the book are skipped after it.
+func (s *BookStorage) DeleteBook(ctx context.Context, b entity.Book) error {
+	bookId, err := uuid.Parse(b.Id)
+	if err != nil {
+		return fmt.Errorf("invalid book id: %w", err)
+	}
+
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		var version int64
+		err := tx.QueryRow(ctx,
+			"SELECT version FROM books WHERE id = $1 FOR UPDATE", bookId).Scan(&version)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to query book: %w", err)
+		}
+		if err == nil && b.Version != 0 && b.Version <= version {
+			slog.Debug("book delete is older than the stored book", slog.String("id", b.Id))
+			return nil
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO deleted_books (id, version) VALUES ($1, $2)
+			ON CONFLICT (id) DO UPDATE SET version = GREATEST(deleted_books.version, EXCLUDED.version)`,
+			bookId, b.Version)
+		if err != nil {
+			return fmt.Errorf("failed to save book tombstone: %w", err)
+		}
+
+		authorIds, err := unlinkAuthors(ctx, tx, []uuid.UUID{bookId})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM books WHERE id = $1", bookId)
+		if err != nil {
+			return fmt.Errorf("failed to delete book: %w", err)
+		}
+
+		return deleteOrphanAuthors(ctx, tx, authorIds)
+	})
+}
+
+// linkAuthors creates missing authors and links them with the books. A single
+// upsert returns the ids of new and existing authors alike, including the ones
+// a concurrent transaction has just inserted, and takes names in order so
+// concurrent saves do not deadlock.
+func linkAuthors(ctx context.Context, tx pgx.Tx, links authorLinks) error {
+	if len(links.names) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx,
+		`WITH a AS (
+			INSERT INTO authors (name)
+			SELECT DISTINCT name FROM unnest($2::text[]) AS name ORDER BY name
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, name
+		)
+		INSERT INTO book_authors (book_id, author_id)
+		SELECT l.book_id, a.id FROM unnest($1::uuid[], $2::text[]) AS l(book_id, name)
+		JOIN a ON a.name = l.name
+		ON CONFLICT DO NOTHING`,
+		links.books, links.names)
+	if err != nil {
+		return fmt.Errorf("failed to link books with authors: %w", err)
+	}
+
+	return nil
+}
+
+func unlinkAuthors(ctx context.Context, tx pgx.Tx, bookIds []uuid.UUID) ([]int64, error) {
+	rows, err := tx.Query(ctx,
+		"DELETE FROM book_authors WHERE book_id = ANY($1) RETURNING author_id", bookIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlink authors: %w", err)
+	}
+
+	authorIds, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlink authors: %w", err)
+	}
+
+	return authorIds, nil
+}
+
+func replaceAuthors(ctx context.Context, tx pgx.Tx, bookIds []uuid.UUID, links authorLinks) error {
+	if len(bookIds) == 0 {
+		return nil
+	}
+
+	authorIds, err := unlinkAuthors(ctx, tx, bookIds)
+	if err != nil {
+		return err
+	}
+
+	if err := linkAuthors(ctx, tx, links); err != nil {
+		return err
+	}
+
+	return deleteOrphanAuthors(ctx, tx, authorIds)
+}
+
+// deleteOrphanAuthors removes the given authors once no book refers to them.
+func deleteOrphanAuthors(ctx context.Context, tx pgx.Tx, authorIds []int64) error {
+	if len(authorIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx,
+		`DELETE FROM authors a WHERE a.id = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = a.id)`,
+		authorIds)
+	if err != nil {
+		return fmt.Errorf("failed to delete orphan authors: %w", err)
+	}
+
+	return nil
 }
 
 func (s *BookStorage) GetCountBooks(ctx context.Context) (int64, error) {
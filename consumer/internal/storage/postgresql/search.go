@@ -0,0 +1,76 @@
+package postgresql
+
+import (
+	"consumer/internal/entity"
+	"consumer/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// undefinedObject is the SQLSTATE of a text search configuration that does not exist.
+const undefinedObject = "42704"
+
+// SearchBooks finds books by words of the title and the text, title matches
+// rank higher. The query supports "quoted phrases", -excluded words and or.
+// Only books saved in the language of the query are searched. Headlines are
+// built for the books of the page only, as they read the whole text.
+func (s *BookStorage) SearchBooks(ctx context.Context, q storage.SearchQuery) ([]entity.BookMatch, error) {
+	language := q.Language
+	if language == "" {
+		language = s.language
+	}
+
+	args := []any{language, q.Query, q.Limit}
+	after := ""
+	if q.After != nil {
+		args = append(args, q.After.Rank, q.After.Id)
+		after = "WHERE (m.rank, m.id) < ($4::real, $5::uuid)"
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`WITH q AS (SELECT websearch_to_tsquery($1::regconfig, $2) AS query)
+		SELECT b.id, b.title, b.version, `+bookAuthors+`, b.rank,
+			ts_headline($1::regconfig, b.title, q.query, 'HighlightAll=true'),
+			ts_headline($1::regconfig, COALESCE(b.text, ''), q.query, 'MaxFragments=3, MaxWords=30, MinWords=10')
+		FROM (
+			SELECT m.* FROM (
+				SELECT b.id, b.title, b.text, b.version, ts_rank(b.search, q.query) AS rank
+				FROM books b, q
+				WHERE b.language = $1::regconfig AND b.search @@ q.query
+			) m `+after+`
+			ORDER BY m.rank DESC, m.id DESC
+			LIMIT $3
+		) b, q
+		ORDER BY b.rank DESC, b.id DESC`,
+		args...)
+	if err != nil {
+		return nil, searchError(err)
+	}
+
+	matches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.BookMatch, error) {
+		var (
+			book  storage.BookRow
+			match entity.BookMatch
+		)
+		err := row.Scan(&book.Id, &book.Title, &book.Version, &book.Authors,
+			&match.Rank, &match.Title, &match.Snippet)
+		match.Book = storage.ToModel(book)
+		return match, err
+	})
+	if err != nil {
+		return nil, searchError(err)
+	}
+
+	return matches, nil
+}
+
+func searchError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == undefinedObject {
+		return fmt.Errorf("%w: %s", storage.ErrUnknownLanguage, pgErr.Message)
+	}
+	return fmt.Errorf("failed to search books: %w", err)
+}
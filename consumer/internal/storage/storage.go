@@ -2,14 +2,146 @@ package storage
 
 import (
 	"consumer/internal/entity"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
 )
 
+var (
+	ErrBookNotFound   = errors.New("book not found")
+	ErrAuthorNotFound = errors.New("author not found")
+	// ErrBookNotCreated is returned for an update of a book that was neither
+	// created nor deleted yet, its create may still be retried. It is a
+	// retryable ErrBookNotFound.
+	ErrBookNotCreated = fmt.Errorf("%w yet", ErrBookNotFound)
+	// ErrUnknownLanguage is returned for a text search configuration Postgres does not have.
+	ErrUnknownLanguage = errors.New("unknown text search language")
+)
+
+// pgErrorClasses names SQLSTATE classes, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+var pgErrorClasses = map[string]string{
+	"08": "connection",
+	"22": "data",
+	"23": "constraint",
+	"40": "rollback",
+	"53": "resources",
+	"57": "operator",
+}
+
+// ErrorClass groups storage errors into a few classes for metrics.
+func ErrorClass(err error) string {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, ErrBookNotFound):
+		return "not_found"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case pgconn.SafeToRetry(err):
+		return "connection"
+	case errors.As(err, &pgErr):
+		if class, ok := pgErrorClasses[pgErr.Code[:2]]; ok {
+			return class
+		}
+		return "postgres"
+	default:
+		return "other"
+	}
+}
+
+// retryableCodes are SQLSTATEs worth retrying as is: serialization failures,
+// deadlocks, lock timeouts and a server that is restarting or overloaded.
+var retryableCodes = map[string]bool{
+	"40001": true,
+	"40P01": true,
+	"55P03": true,
+	"53300": true,
+	"57P01": true,
+	"57P02": true,
+	"57P03": true,
+}
+
+// IsRetryable tells transient storage errors, which may succeed on another
+// attempt, from permanent ones such as constraint violations or bad data. An
+// update that came before its book is retried until the create is saved.
+func IsRetryable(err error) bool {
+	var (
+		pgErr      *pgconn.PgError
+		connectErr *pgconn.ConnectError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, ErrBookNotCreated):
+		return true
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
+		return true
+	case errors.As(err, &pgErr):
+		return retryableCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	case errors.As(err, &connectErr), pgconn.SafeToRetry(err):
+		return true
+	default:
+		return false
+	}
+}
+
+type BookOrder string
+
+const (
+	OrderById    BookOrder = "id"
+	OrderByTitle BookOrder = "title"
+)
+
+// BookCursor is the sort key of the last book of a page, the next page
+// starts right after it.
+type BookCursor struct {
+	Id    uuid.UUID
+	Title string
+}
+
+// BookQuery selects a page of books. Author matches a name exactly, AuthorId
+// an author id when it is not zero, and Title is a case-insensitive substring.
+type BookQuery struct {
+	Author     string
+	AuthorId   int64
+	Title      string
+	OrderBy    BookOrder
+	Descending bool
+	After      *BookCursor
+	Limit      int
+}
+
+// AuthorQuery selects a page of authors ordered by name, starting after the
+// After name when it is set. Prefix is case-insensitive.
+type AuthorQuery struct {
+	Prefix string
+	After  string
+	Limit  int
+}
+
+// SearchQuery selects a page of books matching Query in web search syntax,
+// ordered by rank. An empty Language is the one books are saved with.
+type SearchQuery struct {
+	Query    string
+	Language string
+	After    *SearchCursor
+	Limit    int
+}
+
+type SearchCursor struct {
+	Rank float32
+	Id   uuid.UUID
+}
+
 type BookRow struct {
 	Id      uuid.UUID
 	Title   string
 	Authors []string
 	Text    string
+	Version int64
 }
 
 func FromModel(e entity.Book) BookRow {
@@ -18,6 +150,7 @@ func FromModel(e entity.Book) BookRow {
 		Title:   e.Title,
 		Authors: e.Authors,
 		Text:    e.Text,
+		Version: e.Version,
 	}
 }
 
@@ -27,5 +160,6 @@ func ToModel(e BookRow) entity.Book {
 		Title:   e.Title,
 		Authors: e.Authors,
 		Text:    e.Text,
+		Version: e.Version,
 	}
 }
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"testing"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect string
+	}{
+		{err: fmt.Errorf("failed to update: %w", ErrBookNotFound), expect: "not_found"},
+		{err: ErrBookNotCreated, expect: "not_found"},
+		{err: fmt.Errorf("failed to insert book: %w", context.DeadlineExceeded), expect: "timeout"},
+		{err: fmt.Errorf("failed to insert book: %w", &pgconn.PgError{Code: "23505"}), expect: "constraint"},
+		{err: &pgconn.PgError{Code: "XX000"}, expect: "postgres"},
+		{err: errors.New("boom"), expect: "other"},
+	}
+
+	for _, tt := range tests {
+		if class := ErrorClass(tt.err); class != tt.expect {
+			t.Errorf("expect class %s for %v, but got %s", tt.expect, tt.err, class)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{err: fmt.Errorf("failed to insert book: %w", context.DeadlineExceeded), expect: true},
+		{err: context.Canceled, expect: false},
+		{err: &pgconn.PgError{Code: "40001"}, expect: true},
+		{err: fmt.Errorf("failed to commit: %w", &pgconn.PgError{Code: "40P01"}), expect: true},
+		{err: &pgconn.PgError{Code: "08006"}, expect: true},
+		{err: &pgconn.PgError{Code: "23505"}, expect: false},
+		{err: &pgconn.PgError{Code: "22P02"}, expect: false},
+		{err: fmt.Errorf("failed to update: %w", ErrBookNotFound), expect: false},
+		{err: fmt.Errorf("failed to update: %w", ErrBookNotCreated), expect: true},
+		{err: errors.New("boom"), expect: false},
+	}
+
+	for _, tt := range tests {
+		if retryable := IsRetryable(tt.err); retryable != tt.expect {
+			t.Errorf("expect retryable %t for %v, but got %t", tt.expect, tt.err, retryable)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS books_title_id_idx ON books (title, id);
+CREATE INDEX IF NOT EXISTS book_authors_author_id_idx ON book_authors (author_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS book_authors_author_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books ADD COLUMN IF NOT EXISTS language REGCONFIG NOT NULL DEFAULT 'english';
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector(language, title), 'A') ||
+    setweight(to_tsvector(language, COALESCE(text, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS books_search_idx ON books USING GIN (search);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS books_search_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS search;
+ALTER TABLE books DROP COLUMN IF EXISTS language;
+-- +goose StatementEnd
@@ -5,16 +5,26 @@ import (
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/s-khechnev/pet-project/protos/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"producer/internal/auth"
+	"producer/internal/blob"
 	"producer/internal/config"
 	"producer/internal/handler"
+	"producer/internal/metrics"
+	"producer/internal/middleware"
 	"producer/internal/queue"
 	"producer/internal/service"
+	"producer/internal/spool"
+	"producer/internal/storage/memory"
 	"syscall"
 	"time"
 )
@@ -56,22 +66,125 @@ func main() {
 
 	logger.Info("starting")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, "producer")
+	if err != nil {
+		logger.Error("failed init tracing", slog.String("error", err.Error()))
+		os.Exit(2)
+	}
+
+	statusStorage := memory.NewStatusStorage(cfg.Status.TTL)
+	idempotencyStorage := memory.NewIdempotencyStorage(cfg.Idempotency.TTL)
+
+	var bookSpool *spool.Spool
+	var producerSpool queue.BookSpool
+	if cfg.Spool.Enabled {
+		opened, err := spool.Open(cfg.Spool.Dir, cfg.Spool.SegmentSize)
+		if err != nil {
+			logger.Error("failed open spool", slog.String("error", err.Error()))
+			os.Exit(2)
+		}
+		bookSpool, producerSpool = opened, opened
+		logger.Info("spool is opened", slog.Int("spool_depth", bookSpool.Depth()))
+	}
+
 	kafkaProducer, err := queue.NewKafkaProducer(
 		cfg.Kafka.MessageTopic,
 		&kafka.ConfigMap{
 			"bootstrap.servers": cfg.Kafka.BootstrapServers,
 			"acks":              cfg.Kafka.Acks},
-		cfg.Kafka.FlushTimeout)
+		cfg.Kafka.FlushTimeout,
+		statusStorage,
+		queue.DeliveryMode(cfg.Kafka.DeliveryMode),
+		cfg.Kafka.DeliveryTimeout,
+		producerSpool)
 	if err != nil {
 		logger.Error("failed create Kafka producer", slog.String("error", err.Error()))
 		os.Exit(2)
 	}
 
-	msgService := service.New(kafkaProducer)
-	msgHandler := handler.New(msgService)
+	metrics.RegisterQueueLength(kafkaProducer.Len)
+
+	// forwarded is closed once the spool forwarder has stopped
+	forwarded := make(chan struct{})
+	if bookSpool != nil {
+		metrics.RegisterSpoolDepth(bookSpool.Depth)
+		go func() {
+			defer close(forwarded)
+			kafkaProducer.ForwardSpool(ctx, cfg.Spool.RetryInterval)
+		}()
+	} else {
+		close(forwarded)
+	}
+
+	// statuses are kept in memory, so every instance reads all of them in a
+	// group of its own and has nothing to commit
+	statusConsumer, err := queue.NewStatusConsumer(
+		ctx,
+		statusStorage,
+		cfg.Kafka.StatusTopic,
+		cfg.Kafka.StatusGroupId+"-"+uuid.NewString(),
+		&kafka.ConfigMap{
+			"bootstrap.servers":  cfg.Kafka.BootstrapServers,
+			"auto.offset.reset":  "latest",
+			"enable.auto.commit": false,
+		},
+		cfg.Kafka.PollTimeout,
+	)
+	if err != nil {
+		logger.Error("failed create Kafka status consumer", slog.String("error", err.Error()))
+		os.Exit(2)
+	}
+
+	go statusConsumer.Run()
+
+	blobStore, err := blob.NewFSStore(cfg.Blob.Dir)
+	if err != nil {
+		logger.Error("failed create blob store", slog.String("error", err.Error()))
+		os.Exit(2)
+	}
+
+	quotaStorage := memory.NewQuotaStorage()
+
+	msgService := service.New(kafkaProducer, statusStorage, blobStore, quotaStorage, cfg.Books.InlineTextLimit)
+	msgHandler := handler.New(msgService, cfg.Books)
+
+	healthHandler := handler.NewHealth(map[string]handler.Pinger{
+		"kafka": kafkaProducer,
+	}, cfg.HttpServer.ReadinessTimeout)
 
 	router := gin.Default()
-	router.POST("/books", msgHandler.Post)
+	if err := router.SetTrustedProxies(cfg.HttpServer.TrustedProxies); err != nil {
+		logger.Error("failed set trusted proxies", slog.String("error", err.Error()))
+		os.Exit(2)
+	}
+	// probes are registered before the limits so they are never shed
+	router.GET("/healthz", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.Use(otelgin.Middleware("producer"))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.ConcurrencyLimit(cfg.HttpServer.MaxConcurrentRequests))
+	router.NoRoute(handler.NoRoute)
+
+	books := router.Group("/books")
+	books.Use(middleware.IPRateLimit(cfg.HttpServer.IPRateLimit))
+	if cfg.Auth.Enabled {
+		books.Use(middleware.APIKey(auth.NewKeys(cfg.Auth.Keys)))
+	} else {
+		logger.Warn("api key authentication is disabled")
+	}
+	books.Use(middleware.RateLimit(cfg.HttpServer.RateLimit))
+	bookBody := middleware.BodyLimit(cfg.Books.MaxBodySize)
+	books.POST("", bookBody, middleware.Idempotency(idempotencyStorage, cfg.Books.MaxBodySize), msgHandler.Post)
+	books.POST("/batch", middleware.BodyLimit(cfg.Books.MaxBatchBodySize), msgHandler.PostBatch)
+	books.POST("/import", msgHandler.PostStream)
+	books.GET("/:id/status", msgHandler.GetStatus)
+	books.PUT("/:id", bookBody, msgHandler.Put)
+	books.PATCH("/:id", bookBody, msgHandler.Patch)
+	books.DELETE("/:id", msgHandler.Delete)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.HttpServer.Address, cfg.HttpServer.Port),
@@ -92,13 +205,28 @@ func main() {
 
 	logger.Info("shutdown Server")
 
-	kafkaProducer.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	// handlers may still spool books, the spool is closed after them, the
+	// forwarder and the delivery reports flushed by the producer
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Info("server Shutdown:", slog.String("error", err.Error()))
 	}
+	cancel()
+	<-forwarded
+	kafkaProducer.Close()
+	if bookSpool != nil {
+		if err := bookSpool.Close(); err != nil {
+			logger.Error("failed close spool", slog.String("error", err.Error()))
+		}
+	}
+	if err := statusConsumer.Close(); err != nil {
+		logger.Error("failed close Kafka status consumer", slog.String("error", err.Error()))
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed shutdown tracing", slog.String("error", err.Error()))
+	}
 	logger.Info("server exiting")
 }
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"producer/internal/config"
+	"strings"
+)
+
+// Client is the owner of an API key. Key is the hash of the key, so it can
+// be logged and used to account quotas.
+type Client struct {
+	Key   string
+	Owner string
+	Quota config.Quota
+}
+
+type Keys struct {
+	clients map[string]Client
+}
+
+func NewKeys(keys []config.APIKey) *Keys {
+	clients := make(map[string]Client, len(keys))
+	for _, key := range keys {
+		hash := strings.ToLower(key.Hash)
+		clients[hash] = Client{
+			Key:   hash,
+			Owner: key.Owner,
+			Quota: key.Quota,
+		}
+	}
+
+	return &Keys{clients: clients}
+}
+
+func (k *Keys) Lookup(key string) (Client, bool) {
+	sum := sha256.Sum256([]byte(key))
+	client, ok := k.clients[hex.EncodeToString(sum[:])]
+	return client, ok
+}
+
+type clientKey struct{}
+
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(Client)
+	return client, ok
+}
@@ -0,0 +1,71 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"producer/internal/entity"
+)
+
+// FSStore is a content-addressed blob store on the filesystem: every blob is
+// kept under its sha256, so storing the same text twice is a no-op.
+type FSStore struct {
+	dir string
+}
+
+func NewFSStore(dir string) (*FSStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob dir: %w", err)
+	}
+
+	return &FSStore{dir: dir}, nil
+}
+
+func (s *FSStore) Put(data []byte) (entity.TextRef, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := filepath.Join(hash[:2], hash)
+
+	ref := entity.TextRef{
+		Key:    filepath.ToSlash(key),
+		Sha256: hash,
+		Size:   int64(len(data)),
+	}
+
+	path := filepath.Join(s.dir, key)
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return entity.TextRef{}, fmt.Errorf("failed to create blob dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return entity.TextRef{}, fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return entity.TextRef{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return entity.TextRef{}, fmt.Errorf("failed to sync blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return entity.TextRef{}, fmt.Errorf("failed to close blob: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return entity.TextRef{}, fmt.Errorf("failed to save blob: %w", err)
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFSStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFSStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	data := []byte("War and Peace")
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	ref, err := store.Put(data)
+	if err != nil {
+		t.Fatalf("failed to put blob: %s", err)
+	}
+	if ref.Key != hash[:2]+"/"+hash || ref.Sha256 != hash || ref.Size != int64(len(data)) {
+		t.Fatalf("unexpected ref: %+v", ref)
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(ref.Key))
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read blob: %s", err)
+	}
+	if string(stored) != string(data) {
+		t.Errorf("expect blob %q, but got %q", data, stored)
+	}
+
+	// the same text is not written again
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("failed to change blob time: %s", err)
+	}
+	again, err := store.Put(data)
+	if err != nil {
+		t.Fatalf("failed to put blob again: %s", err)
+	}
+	if again != ref {
+		t.Errorf("expect ref %+v, but got %+v", ref, again)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat blob: %s", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("expect existing blob to be kept, but it was modified at %s", info.ModTime())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read blob dir: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expect only the blob in its dir, but got %d entries", len(entries))
+	}
+}
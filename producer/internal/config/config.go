@@ -2,15 +2,23 @@ package config
 
 import (
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/s-khechnev/pet-project/protos/tracing"
 	"log"
 	"os"
 	"time"
 )
 
 type Config struct {
-	Env        string     `yaml:"env"`
-	HttpServer HttpServer `yaml:"http_server"`
-	Kafka      Kafka      `yaml:"kafka"`
+	Env         string      `yaml:"env"`
+	HttpServer  HttpServer  `yaml:"http_server"`
+	Kafka       Kafka       `yaml:"kafka"`
+	Status      Status      `yaml:"status"`
+	Books       Books       `yaml:"books"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Spool       Spool       `yaml:"spool"`
+	Blob        Blob        `yaml:"blob"`
+	Auth        Auth        `yaml:"auth"`
+	Tracing     Tracing     `yaml:"tracing"`
 }
 
 type HttpServer struct {
@@ -18,6 +26,22 @@ type HttpServer struct {
 	Address     string        `yaml:"address"`
 	Timeout     time.Duration `yaml:"timeout"`
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
+
+	MaxConcurrentRequests int       `yaml:"max_concurrent_requests"`
+	RateLimit             RateLimit `yaml:"rate_limit"`
+	IPRateLimit           RateLimit `yaml:"ip_rate_limit"`
+	// TrustedProxies may set the client IP in forwarding headers, none by default.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout"`
+}
+
+// RateLimit is a token bucket per API key, or per IP without authentication
+// and before it. Zero rate disables it.
+type RateLimit struct {
+	Rate  float64       `yaml:"rate"`
+	Burst int           `yaml:"burst"`
+	TTL   time.Duration `yaml:"ttl"`
 }
 
 type Kafka struct {
@@ -25,7 +49,68 @@ type Kafka struct {
 	MessageTopic     string `yaml:"message_topic"`
 	FlushTimeout     int    `yaml:"flush_timeout"`
 
-	Acks int `yaml:"acks"`
+	Acks            int           `yaml:"acks"`
+	DeliveryMode    string        `yaml:"delivery_mode"`
+	DeliveryTimeout time.Duration `yaml:"delivery_timeout"`
+
+	StatusTopic   string        `yaml:"status_topic"`
+	StatusGroupId string        `yaml:"status_group_id"`
+	PollTimeout   time.Duration `yaml:"poll_timeout"`
+}
+
+// Books limits book requests, zero body sizes disable the body caps.
+type Books struct {
+	MaxBodySize       int64         `yaml:"max_body_size"`
+	InlineTextLimit   int           `yaml:"inline_text_limit"`
+	MaxBatchSize      int           `yaml:"max_batch_size"`
+	MaxBatchBodySize  int64         `yaml:"max_batch_body_size"`
+	MaxLineSize       int           `yaml:"max_line_size"`
+	StreamIdleTimeout time.Duration `yaml:"stream_idle_timeout"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type Spool struct {
+	Enabled       bool          `yaml:"enabled"`
+	Dir           string        `yaml:"dir"`
+	SegmentSize   int64         `yaml:"segment_size"`
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+type Blob struct {
+	Dir string `yaml:"dir"`
+}
+
+// Auth keys are listed inline or, outside of local runs, in KeysFile: a yaml
+// file with the same keys list mounted from a secret.
+type Auth struct {
+	Enabled  bool     `yaml:"enabled"`
+	Keys     []APIKey `yaml:"keys"`
+	KeysFile string   `yaml:"keys_file" env:"API_KEYS_FILE"`
+}
+
+// APIKey is stored as a hex sha256 of the key, the key itself never gets into config.
+type APIKey struct {
+	Owner string `yaml:"owner"`
+	Hash  string `yaml:"hash"`
+	Quota Quota  `yaml:"quota"`
+}
+
+// Quota limits books and text bytes per key, zero means no limit.
+type Quota struct {
+	HourlyBooks int64 `yaml:"hourly_books"`
+	DailyBooks  int64 `yaml:"daily_books"`
+	HourlyBytes int64 `yaml:"hourly_bytes"`
+	DailyBytes  int64 `yaml:"daily_bytes"`
+}
+
+// Tracing is shared by the services.
+type Tracing = tracing.Config
+
+type Status struct {
+	TTL time.Duration `yaml:"ttl"`
 }
 
 const ConfigPathVar = "CONFIG_PATH"
@@ -45,5 +130,18 @@ func GetConfig() *Config {
 		log.Fatalf("cannot read config file: %s", err)
 	}
 
+	if config.Auth.KeysFile != "" {
+		var keys struct {
+			Keys []APIKey `yaml:"keys"`
+		}
+		if err := cleanenv.ReadConfig(config.Auth.KeysFile, &keys); err != nil {
+			log.Fatalf("cannot read api keys file: %s", err)
+		}
+		config.Auth.Keys = append(config.Auth.Keys, keys.Keys...)
+	}
+	if config.Auth.Enabled && len(config.Auth.Keys) == 0 {
+		log.Fatal("auth is enabled, but no api keys are configured")
+	}
+
 	return &config
 }
@@ -5,4 +5,12 @@ type Book struct {
 	Title   string   `json:"title"`
 	Authors []string `json:"authors"`
 	Text    string   `json:"text"`
+	TextRef *TextRef `json:"text_ref,omitempty"`
+}
+
+// TextRef points to a book text kept in the blob store instead of the message.
+type TextRef struct {
+	Key    string `json:"key"`
+	Sha256 string `json:"sha256"`
+	Size   int64  `json:"size"`
 }
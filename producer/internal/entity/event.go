@@ -0,0 +1,25 @@
+package entity
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+const (
+	FieldTitle   = "title"
+	FieldAuthors = "authors"
+	FieldText    = "text"
+)
+
+// BookEvent is a change of a book. Fields lists the fields changed by a
+// partial update; an update without fields replaces the whole book. Client is
+// the owner of the API key the change came with.
+type BookEvent struct {
+	Type   EventType
+	Book   Book
+	Fields []string
+	Client string
+}
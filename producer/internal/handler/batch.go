@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type ItemResult struct {
+	Id string `json:"id,omitempty"`
+	*APIError
+}
+
+type BatchItemResult struct {
+	Index int `json:"index"`
+	ItemResult
+}
+
+type BatchResponse struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Results  []BatchItemResult `json:"results"`
+}
+
+func (h *BookHandler) PostBatch(c *gin.Context) {
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if len(items) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, newError(CodeBatchEmpty, "batch is empty"))
+		return
+	}
+
+	if len(items) > h.config.MaxBatchSize {
+		c.JSON(http.StatusRequestEntityTooLarge,
+			newError(CodeBatchTooLarge, fmt.Sprintf("batch size %d exceeds limit %d", len(items), h.config.MaxBatchSize)))
+		return
+	}
+
+	// in sync delivery mode every item waits for its acknowledgement, so the
+	// deadlines move like those of a stream instead of covering the whole batch
+	rc := http.NewResponseController(c.Writer)
+
+	resp := BatchResponse{Results: make([]BatchItemResult, 0, len(items))}
+	for i, item := range items {
+		h.extendDeadlines(rc)
+		result := h.postItem(c.Request.Context(), item)
+		if result.Id != "" {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+		resp.Results = append(resp.Results, BatchItemResult{Index: i, ItemResult: result})
+	}
+
+	code := http.StatusCreated
+	if resp.Rejected > 0 {
+		code = http.StatusMultiStatus
+	}
+	c.JSON(code, resp)
+}
+
+func (h *BookHandler) postItem(ctx context.Context, item []byte) ItemResult {
+	var req PostBookRequest
+	if err := json.Unmarshal(item, &req); err != nil {
+		return ItemResult{APIError: bindError(err)}
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return ItemResult{APIError: validationError(err)}
+	}
+
+	id, err := h.service.Post(ctx, req)
+	if err != nil {
+		_, apiErr := serviceError(err)
+		return ItemResult{APIError: apiErr}
+	}
+
+	return ItemResult{Id: id}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
+
+	router := gin.Default()
+	router.POST("/books/batch", func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 1<<10)
+	}, handler.PostBatch)
+
+	tests := []struct {
+		name         string
+		body         string
+		posts        int
+		expectCode   int
+		expectResult []BatchItemResult
+	}{
+		{
+			name:       "cant parse body",
+			body:       `{"title":"War and Peace"}`,
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "empty batch",
+			body:       `[]`,
+			expectCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "batch too large",
+			body:       `[{}, {}, {}]`,
+			expectCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "body too large",
+			body:       `[{"text":"` + strings.Repeat("1", 1<<10) + `"}]`,
+			expectCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "all accepted",
+			body:       `[{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}, {"title":"Anna Karenina", "authors":["Lev Tolstoi"], "text":"456"}]`,
+			posts:      2,
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:       "partially rejected",
+			body:       `[{"title":"", "authors":["Lev Tolstoi"], "text":"123"}, {"title":"Anna Karenina", "authors":["Lev Tolstoi"], "text":"456"}]`,
+			posts:      1,
+			expectCode: http.StatusMultiStatus,
+			expectResult: []BatchItemResult{
+				{Index: 0, ItemResult: ItemResult{APIError: &APIError{
+					Code:   CodeValidationFailed,
+					Fields: []FieldError{{Field: "title", Code: FieldRequired, Rule: "required"}},
+				}}},
+				{Index: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookService.EXPECT().Post(gomock.Any(), gomock.Any()).Return(uuid.New().String(), nil).Times(tt.posts)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/books/batch", strings.NewReader(tt.body))
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Fatalf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+
+			if tt.expectResult == nil {
+				return
+			}
+
+			var resp BatchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %s", err)
+			}
+
+			if len(resp.Results) != len(tt.expectResult) {
+				t.Fatalf("expect %d results, but got %d", len(tt.expectResult), len(resp.Results))
+			}
+
+			for i, expect := range tt.expectResult {
+				actual := resp.Results[i]
+				if actual.Index != expect.Index {
+					t.Errorf("expect index %d, but got %d", expect.Index, actual.Index)
+				}
+				if expect.APIError == nil {
+					if actual.Id == "" {
+						t.Errorf("item %d: expect id, but got error %+v", i, actual.APIError)
+					}
+					continue
+				}
+				if actual.APIError == nil || actual.Code != expect.Code {
+					t.Fatalf("item %d: expect error code %s, but got %+v", i, expect.Code, actual.APIError)
+				}
+				if len(actual.Fields) != len(expect.Fields) {
+					t.Fatalf("item %d: expect fields %v, but got %v", i, expect.Fields, actual.Fields)
+				}
+				for j, field := range expect.Fields {
+					got := actual.Fields[j]
+					if got.Field != field.Field || got.Code != field.Code || got.Rule != field.Rule {
+						t.Errorf("item %d: expect field %+v, but got %+v", i, field, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostBatchDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 3, StreamIdleTimeout: time.Second})
+
+	router := gin.Default()
+	router.POST("/books/batch", handler.PostBatch)
+
+	// every item takes most of the write timeout, as a synchronous delivery may
+	bookService.EXPECT().Post(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, PostBookRequest) (string, error) {
+		time.Sleep(60 * time.Millisecond)
+		return uuid.New().String(), nil
+	}).Times(3)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	book := `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`
+	resp, err := http.Post(server.URL+"/books/batch", "application/json", strings.NewReader("["+book+","+book+","+book+"]"))
+	if err != nil {
+		t.Fatalf("expect batch to outlive the write timeout, but got %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expect code %d, but got %d", http.StatusCreated, resp.StatusCode)
+	}
+	var batch BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if batch.Accepted != 3 {
+		t.Errorf("expect 3 accepted books, but got %d", batch.Accepted)
+	}
+}
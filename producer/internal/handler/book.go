@@ -1,52 +1,176 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"net/http"
+	"producer/internal/config"
+	"producer/internal/entity"
+	"reflect"
+	"strings"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+var (
+	ErrBookNotFound    = errors.New("book not found")
+	ErrDeliveryFailed  = errors.New("book is not delivered")
+	ErrDeliveryTimeout = errors.New("book delivery is not acknowledged in time")
+	ErrEmptyPatch      = errors.New("no fields to update")
+)
 
 type BookService interface {
-	Post(request PostBookRequest) (string, error)
+	Post(ctx context.Context, request PostBookRequest) (string, error)
+	Put(ctx context.Context, id string, request PostBookRequest) error
+	Patch(ctx context.Context, id string, request PatchBookRequest) error
+	Delete(ctx context.Context, id string) error
+	Status(id string) (entity.BookStatus, error)
 }
 
 type BookHandler struct {
 	service BookService
+	config  config.Books
 }
 
-func New(service BookService) *BookHandler {
-	return &BookHandler{service: service}
+func New(service BookService, cfg config.Books) *BookHandler {
+	return &BookHandler{
+		service: service,
+		config:  cfg,
+	}
 }
 
 type PostBookRequest struct {
 	Title   string   `json:"title" validate:"required,gte=1,lte=255"`
 	Authors []string `json:"authors" validate:"gte=1,lte=255"`
-	Text    string   `json:"text" validate:"gte=1,lte=10000"`
+	Text    string   `json:"text" validate:"gte=1,lte=10000000"`
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+type PatchBookRequest struct {
+	Title   *string   `json:"title" validate:"omitempty,gte=1,lte=255"`
+	Authors *[]string `json:"authors" validate:"omitempty,gte=1,lte=255"`
+	Text    *string   `json:"text" validate:"omitempty,gte=1,lte=10000000"`
 }
 
 func (h *BookHandler) Post(c *gin.Context) {
 	var req PostBookRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
 		return
 	}
 
 	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		c.JSON(http.StatusUnprocessableEntity, validationError(err))
 		return
 	}
 
-	id, err := h.service.Post(req)
+	id, err := h.service.Post(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
+
+func (h *BookHandler) Put(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, newError(CodeInvalidId, "book id must be a uuid"))
+		return
+	}
+
+	var req PostBookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, validationError(err))
+		return
+	}
+
+	if err := h.service.Put(c.Request.Context(), id, req); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": id})
+}
+
+func (h *BookHandler) Patch(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, newError(CodeInvalidId, "book id must be a uuid"))
+		return
+	}
+
+	var req PatchBookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if req.Title == nil && req.Authors == nil && req.Text == nil {
+		c.JSON(http.StatusUnprocessableEntity, newError(CodeEmptyPatch, ErrEmptyPatch.Error()))
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, validationError(err))
+		return
+	}
+
+	if err := h.service.Patch(c.Request.Context(), id, req); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": id})
+}
+
+func (h *BookHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, newError(CodeInvalidId, "book id must be a uuid"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": id})
+}
+
+func (h *BookHandler) GetStatus(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, newError(CodeInvalidId, "book id must be a uuid"))
+		return
+	}
+
+	status, err := h.service.Status(id)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -10,6 +10,8 @@
 package handler
 
 import (
+	context "context"
+	entity "producer/internal/entity"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -39,17 +41,74 @@ func (m *MockBookService) EXPECT() *MockBookServiceMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockBookService) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBookServiceMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBookService)(nil).Delete), ctx, id)
+}
+
+// Patch mocks base method.
+func (m *MockBookService) Patch(ctx context.Context, id string, request PatchBookRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockBookServiceMockRecorder) Patch(ctx, id, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockBookService)(nil).Patch), ctx, id, request)
+}
+
 // Post mocks base method.
-func (m *MockBookService) Post(request PostBookRequest) (string, error) {
+func (m *MockBookService) Post(ctx context.Context, request PostBookRequest) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Post", request)
+	ret := m.ctrl.Call(m, "Post", ctx, request)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Post indicates an expected call of Post.
-func (mr *MockBookServiceMockRecorder) Post(request any) *gomock.Call {
+func (mr *MockBookServiceMockRecorder) Post(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockBookService)(nil).Post), ctx, request)
+}
+
+// Put mocks base method.
+func (m *MockBookService) Put(ctx context.Context, id string, request PostBookRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, id, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBookServiceMockRecorder) Put(ctx, id, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBookService)(nil).Put), ctx, id, request)
+}
+
+// Status mocks base method.
+func (m *MockBookService) Status(id string) (entity.BookStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", id)
+	ret0, _ := ret[0].(entity.BookStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockBookServiceMockRecorder) Status(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockBookService)(nil).Post), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockBookService)(nil).Status), id)
 }
@@ -1,20 +1,25 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/mock/gomock"
 	"net/http"
 	"net/http/httptest"
+	"producer/internal/config"
+	"producer/internal/entity"
+	"producer/internal/storage"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	bookService := NewMockBookService(ctrl)
 
-	handler := New(bookService)
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
 
 	router := gin.Default()
 	router.POST("/books", handler.Post)
@@ -44,7 +49,7 @@ func Test(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.expectCode >= 200 && tt.expectCode <= 299 {
-				bookService.EXPECT().Post(gomock.Any()).Return(uuid.New().String(), nil).Times(1)
+				bookService.EXPECT().Post(gomock.Any(), gomock.Any()).Return(uuid.New().String(), nil).Times(1)
 			}
 
 			w := httptest.NewRecorder()
@@ -57,3 +62,230 @@ func Test(t *testing.T) {
 		})
 	}
 }
+
+func TestGetStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
+
+	router := gin.Default()
+	router.GET("/books/:id/status", handler.GetStatus)
+
+	knownId := uuid.New().String()
+	unknownId := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		id         string
+		status     entity.BookStatus
+		err        error
+		expectCode int
+	}{
+		{
+			name:       "invalid id",
+			id:         "not-a-uuid",
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown book",
+			id:         unknownId,
+			err:        ErrBookNotFound,
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "known book",
+			id:         knownId,
+			status:     entity.BookStatus{Id: knownId, Status: entity.StatusSaved},
+			expectCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectCode != http.StatusBadRequest {
+				bookService.EXPECT().Status(tt.id).Return(tt.status, tt.err).Times(1)
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/books/"+tt.id+"/status", nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Errorf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestPostServiceErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
+
+	router := gin.Default()
+	router.POST("/books", handler.Post)
+
+	tests := []struct {
+		name       string
+		err        error
+		expectCode int
+	}{
+		{
+			name:       "produce error",
+			err:        errors.New("produce book error"),
+			expectCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "delivery failed",
+			err:        ErrDeliveryFailed,
+			expectCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "delivery timeout",
+			err:        ErrDeliveryTimeout,
+			expectCode: http.StatusGatewayTimeout,
+		},
+		{
+			name: "quota exceeded",
+			err: &storage.QuotaExceededError{
+				Resource: "books",
+				Window:   "hourly",
+				Limit:    10,
+				ResetAt:  time.Now().Add(time.Minute),
+			},
+			expectCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookService.EXPECT().Post(gomock.Any(), gomock.Any()).Return(uuid.Nil.String(), tt.err).Times(1)
+
+			w := httptest.NewRecorder()
+			body := `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`
+			req, _ := http.NewRequest(http.MethodPost, "/books", strings.NewReader(body))
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Errorf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+			if tt.expectCode == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Errorf("expect Retry-After header on exceeded quota")
+			}
+		})
+	}
+}
+
+func TestModify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
+
+	router := gin.Default()
+	router.PUT("/books/:id", handler.Put)
+	router.PATCH("/books/:id", handler.Patch)
+	router.DELETE("/books/:id", handler.Delete)
+
+	id := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		method     string
+		id         string
+		body       string
+		expect     func()
+		expectCode int
+	}{
+		{
+			name:       "put invalid id",
+			method:     http.MethodPut,
+			id:         "123",
+			body:       `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`,
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "put invalid book",
+			method:     http.MethodPut,
+			id:         id,
+			body:       `{"title":"", "text":"123"}`,
+			expectCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "put success",
+			method: http.MethodPut,
+			id:     id,
+			body:   `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`,
+			expect: func() {
+				bookService.EXPECT().Put(gomock.Any(), id, gomock.Any()).Return(nil).Times(1)
+			},
+			expectCode: http.StatusAccepted,
+		},
+		{
+			name:       "patch without fields",
+			method:     http.MethodPatch,
+			id:         id,
+			body:       `{}`,
+			expectCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "patch invalid field",
+			method:     http.MethodPatch,
+			id:         id,
+			body:       `{"title":""}`,
+			expectCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "patch success",
+			method: http.MethodPatch,
+			id:     id,
+			body:   `{"title":"Anna Karenina"}`,
+			expect: func() {
+				bookService.EXPECT().Patch(gomock.Any(), id, gomock.Any()).
+					DoAndReturn(func(_ any, _ string, req PatchBookRequest) error {
+						if req.Title == nil || *req.Title != "Anna Karenina" || req.Authors != nil || req.Text != nil {
+							t.Errorf("unexpected patch request %+v", req)
+						}
+						return nil
+					}).Times(1)
+			},
+			expectCode: http.StatusAccepted,
+		},
+		{
+			name:   "delete success",
+			method: http.MethodDelete,
+			id:     id,
+			expect: func() {
+				bookService.EXPECT().Delete(gomock.Any(), id).Return(nil).Times(1)
+			},
+			expectCode: http.StatusAccepted,
+		},
+		{
+			name:   "delete service error",
+			method: http.MethodDelete,
+			id:     id,
+			expect: func() {
+				bookService.EXPECT().Delete(gomock.Any(), id).Return(ErrDeliveryFailed).Times(1)
+			},
+			expectCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expect != nil {
+				tt.expect()
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, "/books/"+tt.id, strings.NewReader(tt.body))
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Errorf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+		})
+	}
+}
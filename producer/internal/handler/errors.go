@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"math"
+	"net/http"
+	"producer/internal/storage"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ErrorCode is a stable machine-readable error identifier, clients should
+// match on it instead of the human message.
+type ErrorCode string
+
+const (
+	CodeInvalidBody          ErrorCode = "invalid_body"
+	CodeBodyTooLarge         ErrorCode = "body_too_large"
+	CodeValidationFailed     ErrorCode = "validation_failed"
+	CodeInvalidId            ErrorCode = "invalid_id"
+	CodeEmptyPatch           ErrorCode = "empty_patch"
+	CodeBatchEmpty           ErrorCode = "batch_empty"
+	CodeBatchTooLarge        ErrorCode = "batch_too_large"
+	CodeUnsupportedMediaType ErrorCode = "unsupported_media_type"
+	CodeStreamReadFailed     ErrorCode = "stream_read_failed"
+	CodeBookNotFound         ErrorCode = "book_not_found"
+	CodeDeliveryFailed       ErrorCode = "delivery_failed"
+	CodeDeliveryTimeout      ErrorCode = "delivery_timeout"
+	CodeInternal             ErrorCode = "internal_error"
+	CodeRouteNotFound        ErrorCode = "route_not_found"
+	CodeUnauthorized         ErrorCode = "unauthorized"
+	CodeQuotaExceeded        ErrorCode = "quota_exceeded"
+	CodeRateLimited          ErrorCode = "rate_limited"
+	CodeOverloaded           ErrorCode = "overloaded"
+
+	CodeIdempotencyKeyTooLong    ErrorCode = "idempotency_key_too_long"
+	CodeIdempotencyKeyMismatch   ErrorCode = "idempotency_key_mismatch"
+	CodeIdempotencyKeyInProgress ErrorCode = "idempotency_key_in_progress"
+)
+
+// FieldErrorCode tells what is wrong with a single field.
+type FieldErrorCode string
+
+const (
+	FieldRequired    FieldErrorCode = "required"
+	FieldTooShort    FieldErrorCode = "too_short"
+	FieldTooLong     FieldErrorCode = "too_long"
+	FieldInvalidType FieldErrorCode = "invalid_type"
+	FieldInvalid     FieldErrorCode = "invalid"
+)
+
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    FieldErrorCode `json:"code"`
+	Rule    string         `json:"rule"`
+	Limit   string         `json:"limit,omitempty"`
+	Message string         `json:"message"`
+}
+
+// Quota tells the client which quota is exhausted and when it resets.
+type Quota struct {
+	Resource string    `json:"resource"`
+	Window   string    `json:"window"`
+	Limit    int64     `json:"limit"`
+	ResetAt  time.Time `json:"reset_at"`
+}
+
+// APIError is the body of every error response of the producer API.
+type APIError struct {
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	Quota   *Quota       `json:"quota,omitempty"`
+}
+
+func newError(code ErrorCode, message string) *APIError {
+	return &APIError{Code: code, Message: message}
+}
+
+func bindError(err error) *APIError {
+	var (
+		syntaxError *json.SyntaxError
+		typeError   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return newError(CodeInvalidBody, "request body is empty")
+	case errors.As(err, &syntaxError):
+		return newError(CodeInvalidBody, fmt.Sprintf("malformed json at offset %d", syntaxError.Offset))
+	case errors.As(err, &typeError) && typeError.Field != "":
+		apiErr := newError(CodeInvalidBody, "request body has fields of a wrong type")
+		apiErr.Fields = []FieldError{{
+			Field:   typeError.Field,
+			Code:    FieldInvalidType,
+			Rule:    "type",
+			Limit:   typeError.Type.String(),
+			Message: fmt.Sprintf("%s must be %s", typeError.Field, typeError.Type),
+		}}
+		return apiErr
+	default:
+		return newError(CodeInvalidBody, err.Error())
+	}
+}
+
+// BodyTooLarge is the error of a request whose body exceeds limit bytes.
+func BodyTooLarge(limit int64) *APIError {
+	return newError(CodeBodyTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
+
+// writeBindError answers 413 when the body is over the limit set with
+// http.MaxBytesReader, and 400 when it cannot be parsed.
+func writeBindError(c *gin.Context, err error) {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		c.JSON(http.StatusRequestEntityTooLarge, BodyTooLarge(maxBytesError.Limit))
+		return
+	}
+	c.JSON(http.StatusBadRequest, bindError(err))
+}
+
+func validationError(err error) *APIError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return newError(CodeValidationFailed, err.Error())
+	}
+
+	apiErr := newError(CodeValidationFailed, "validation failed")
+	apiErr.Fields = make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		apiErr.Fields = append(apiErr.Fields, fieldError(fe))
+	}
+	return apiErr
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	result := FieldError{
+		Field: fe.Field(),
+		Code:  FieldInvalid,
+		Rule:  fe.Tag(),
+		Limit: fe.Param(),
+	}
+
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		result.Code = FieldRequired
+		result.Message = fmt.Sprintf("%s is required", fe.Field())
+	case "gte", "min":
+		result.Code = FieldTooShort
+		result.Message = fmt.Sprintf("%s must have at least %s%s", fe.Field(), fe.Param(), unit)
+	case "lte", "max":
+		result.Code = FieldTooLong
+		result.Message = fmt.Sprintf("%s must have at most %s%s", fe.Field(), fe.Param(), unit)
+	default:
+		result.Message = fmt.Sprintf("%s failed on the %s rule", fe.Field(), fe.Tag())
+	}
+
+	return result
+}
+
+func serviceError(err error) (int, *APIError) {
+	var quotaError *storage.QuotaExceededError
+	switch {
+	case errors.As(err, &quotaError):
+		apiErr := newError(CodeQuotaExceeded, err.Error())
+		apiErr.Quota = &Quota{
+			Resource: quotaError.Resource,
+			Window:   quotaError.Window,
+			Limit:    quotaError.Limit,
+			ResetAt:  quotaError.ResetAt,
+		}
+		return http.StatusTooManyRequests, apiErr
+	case errors.Is(err, ErrBookNotFound):
+		return http.StatusNotFound, newError(CodeBookNotFound, err.Error())
+	case errors.Is(err, ErrDeliveryFailed):
+		return http.StatusServiceUnavailable, newError(CodeDeliveryFailed, err.Error())
+	case errors.Is(err, ErrDeliveryTimeout):
+		return http.StatusGatewayTimeout, newError(CodeDeliveryTimeout, err.Error())
+	default:
+		return http.StatusInternalServerError, newError(CodeInternal, err.Error())
+	}
+}
+
+func writeServiceError(c *gin.Context, err error) {
+	code, apiErr := serviceError(err)
+	if apiErr.Quota != nil {
+		retryAfter := int64(math.Ceil(time.Until(apiErr.Quota.ResetAt).Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+	}
+	c.JSON(code, apiErr)
+}
+
+func NoRoute(c *gin.Context) {
+	c.JSON(http.StatusNotFound, newError(CodeRouteNotFound, "route "+c.Request.Method+" "+c.Request.URL.Path+" is not found"))
+}
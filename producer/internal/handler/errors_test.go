@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestErrorBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxBatchSize: 2})
+
+	router := gin.Default()
+	router.POST("/books", handler.Post)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectCode   int
+		expectError  ErrorCode
+		expectFields []FieldError
+	}{
+		{
+			name:        "malformed json",
+			body:        "invalid json",
+			expectCode:  http.StatusBadRequest,
+			expectError: CodeInvalidBody,
+		},
+		{
+			name:        "wrong field type",
+			body:        `{"title":1, "authors":["Lev Tolstoi"], "text":"123"}`,
+			expectCode:  http.StatusBadRequest,
+			expectError: CodeInvalidBody,
+			expectFields: []FieldError{
+				{Field: "title", Code: FieldInvalidType, Rule: "type", Limit: "string"},
+			},
+		},
+		{
+			name:        "invalid fields",
+			body:        `{"title":"", "authors":[], "text":"123"}`,
+			expectCode:  http.StatusUnprocessableEntity,
+			expectError: CodeValidationFailed,
+			expectFields: []FieldError{
+				{Field: "title", Code: FieldRequired, Rule: "required"},
+				{Field: "authors", Code: FieldTooShort, Rule: "gte", Limit: "1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/books", strings.NewReader(tt.body))
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Fatalf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+
+			var resp APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %s", err)
+			}
+
+			if resp.Code != tt.expectError || resp.Message == "" {
+				t.Errorf("expect error code %s, but got %+v", tt.expectError, resp)
+			}
+			if len(resp.Fields) != len(tt.expectFields) {
+				t.Fatalf("expect fields %+v, but got %+v", tt.expectFields, resp.Fields)
+			}
+			for i, expect := range tt.expectFields {
+				actual := resp.Fields[i]
+				if actual.Field != expect.Field || actual.Code != expect.Code ||
+					actual.Rule != expect.Rule || actual.Limit != expect.Limit || actual.Message == "" {
+					t.Errorf("expect field %+v, but got %+v", expect, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	checks  map[string]Pinger
+	timeout time.Duration
+}
+
+// NewHealth returns a handler whose readiness depends on all checks passing
+// within the timeout.
+func NewHealth(checks map[string]Pinger, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+const (
+	healthOk   = "ok"
+	healthFail = "fail"
+)
+
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: healthOk})
+}
+
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	resp := HealthResponse{Status: healthOk, Checks: make(map[string]string, len(h.checks))}
+	for name, check := range h.checks {
+		if err := check.Ping(ctx); err != nil {
+			slog.Warn("readiness check failed", slog.String("check", name), slog.String("error", err.Error()))
+			resp.Status = healthFail
+			resp.Checks[name] = err.Error()
+			continue
+		}
+		resp.Checks[name] = healthOk
+	}
+
+	code := http.StatusOK
+	if resp.Status != healthOk {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, resp)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type pingFunc func(ctx context.Context) error
+
+func (f pingFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealth(t *testing.T) {
+	var kafkaErr error
+	health := NewHealth(map[string]Pinger{
+		"kafka": pingFunc(func(context.Context) error { return kafkaErr }),
+	}, time.Second)
+
+	router := gin.Default()
+	router.GET("/healthz", health.Live)
+	router.GET("/readyz", health.Ready)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("expect ready, but got %d", code)
+	}
+
+	kafkaErr = errors.New("all brokers are down")
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expect not ready, but got %d", code)
+	}
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("expect live while kafka is down, but got %d", code)
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+type StreamLineResult struct {
+	Line int `json:"line"`
+	ItemResult
+}
+
+type StreamSummary struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+	*APIError
+}
+
+func (h *BookHandler) PostStream(c *gin.Context) {
+	if c.ContentType() != ndjsonContentType {
+		c.JSON(http.StatusUnsupportedMediaType,
+			newError(CodeUnsupportedMediaType, "content type must be "+ndjsonContentType))
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.EnableFullDuplex(); err != nil {
+		slog.Debug("full duplex is not supported", slog.String("error", err.Error()))
+	}
+
+	c.Header("Content-Type", ndjsonContentType)
+	c.Status(http.StatusOK)
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, min(64*1024, h.config.MaxLineSize)), h.config.MaxLineSize)
+	encoder := json.NewEncoder(c.Writer)
+
+	var summary StreamSummary
+	line := 0
+	for {
+		h.extendDeadlines(rc)
+		if !scanner.Scan() {
+			break
+		}
+		line++
+
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		result := h.postItem(c.Request.Context(), raw)
+		if result.Id != "" {
+			summary.Accepted++
+		} else {
+			summary.Rejected++
+		}
+
+		if err := encoder.Encode(StreamLineResult{Line: line, ItemResult: result}); err != nil {
+			slog.Error("failed to write stream result", slog.Int("line", line), slog.String("error", err.Error()))
+			return
+		}
+		c.Writer.Flush()
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error("failed to read stream", slog.Int("line", line+1), slog.String("error", err.Error()))
+		summary.APIError = newError(CodeStreamReadFailed, err.Error())
+	}
+
+	if err := encoder.Encode(summary); err != nil {
+		slog.Error("failed to write stream summary", slog.String("error", err.Error()))
+	}
+}
+
+// extendDeadlines gives a request that handles books one by one another
+// StreamIdleTimeout for the next book.
+func (h *BookHandler) extendDeadlines(rc *http.ResponseController) {
+	if h.config.StreamIdleTimeout <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(h.config.StreamIdleTimeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
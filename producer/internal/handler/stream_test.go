@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestPostStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := NewMockBookService(ctrl)
+
+	handler := New(bookService, config.Books{MaxLineSize: 1024})
+
+	router := gin.Default()
+	router.POST("/books/import", handler.PostStream)
+
+	t.Run("wrong content type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/books/import", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("expect code %d, but got %d", http.StatusUnsupportedMediaType, w.Code)
+		}
+	})
+
+	t.Run("per line results", func(t *testing.T) {
+		bookService.EXPECT().Post(gomock.Any(), gomock.Any()).Return(uuid.New().String(), nil).Times(1)
+
+		body := strings.Join([]string{
+			`{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`,
+			``,
+			`{"title":"", "authors":["Lev Tolstoi"], "text":"123"}`,
+			`invalid json`,
+			`{"title":"` + strings.Repeat("a", 2048) + `"}`,
+			`{"title":"never read", "authors":["Lev Tolstoi"], "text":"123"}`,
+		}, "\n")
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/books/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", ndjsonContentType)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expect code %d, but got %d", http.StatusOK, w.Code)
+		}
+
+		scanner := bufio.NewScanner(w.Body)
+		var results []StreamLineResult
+		var summary StreamSummary
+		for scanner.Scan() {
+			var result StreamLineResult
+			if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal line: %s", err)
+			}
+			if result.Line == 0 {
+				if err := json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+					t.Fatalf("failed to unmarshal summary: %s", err)
+				}
+				continue
+			}
+			results = append(results, result)
+		}
+
+		expectLines := []int{1, 3, 4}
+		if len(results) != len(expectLines) {
+			t.Fatalf("expect %d results, but got %d", len(expectLines), len(results))
+		}
+		for i, line := range expectLines {
+			if results[i].Line != line {
+				t.Errorf("expect line %d, but got %d", line, results[i].Line)
+			}
+		}
+		if results[0].Id == "" || results[1].Id != "" || results[2].Id != "" {
+			t.Errorf("unexpected results: %+v", results)
+		}
+		if summary.Accepted != 1 || summary.Rejected != 2 || summary.APIError == nil {
+			t.Errorf("unexpected summary: %+v", summary)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "producer"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ProduceAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "produce_attempts_total",
+		Help:      "Attempts to hand a book to Kafka by result: enqueued, rejected by the client or spooled.",
+	}, []string{"result"})
+
+	Deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "deliveries_total",
+		Help:      "Delivery reports by result.",
+	}, []string{"result"})
+
+	DeliveryLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "delivery_latency_seconds",
+		Help:      "Time from accepting a book to its delivery report, including time in the spool.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+
+	SpoolSkipped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "spool",
+		Name:      "skipped_total",
+		Help:      "Corrupted spool records, or unreadable rests of segments, dropped by the forwarder.",
+	})
+)
+
+const (
+	ResultEnqueued = "enqueued"
+	ResultRejected = "rejected"
+	ResultSpooled  = "spooled"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// RegisterQueueLength exposes the number of messages waiting in the local
+// librdkafka queue.
+func RegisterQueueLength(length func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "queue_length",
+		Help:      "Messages in the local librdkafka queue waiting for delivery.",
+	}, func() float64 {
+		return float64(length())
+	})
+}
+
+// RegisterSpoolDepth exposes the number of books waiting in the spool.
+func RegisterSpoolDepth(depth func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "spool",
+		Name:      "depth",
+		Help:      "Books in the on-disk spool waiting to be forwarded to Kafka.",
+	}, func() float64 {
+		return float64(depth())
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"producer/internal/auth"
+	"producer/internal/handler"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type KeyStore interface {
+	Lookup(key string) (auth.Client, bool)
+}
+
+// APIKey authenticates requests by the X-API-Key header and puts the client
+// into the request context.
+func APIKey(keys KeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &handler.APIError{
+				Code:    handler.CodeUnauthorized,
+				Message: "api key is required",
+			})
+			return
+		}
+
+		client, ok := keys.Lookup(key)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &handler.APIError{
+				Code:    handler.CodeUnauthorized,
+				Message: "api key is invalid",
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithClient(c.Request.Context(), client))
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/auth"
+	"producer/internal/config"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret"))
+	keys := auth.NewKeys([]config.APIKey{{Owner: "library", Hash: hex.EncodeToString(sum[:])}})
+
+	var owner string
+	router := gin.Default()
+	router.GET("/books", APIKey(keys), func(c *gin.Context) {
+		client, _ := auth.ClientFromContext(c.Request.Context())
+		owner = client.Owner
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		key        string
+		expectCode int
+	}{
+		{name: "no key", expectCode: http.StatusUnauthorized},
+		{name: "unknown key", key: "guess", expectCode: http.StatusUnauthorized},
+		{name: "valid key", key: "secret", expectCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/books", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Errorf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+		})
+	}
+
+	if owner != "library" {
+		t.Errorf("expect client library in context, but got %q", owner)
+	}
+}
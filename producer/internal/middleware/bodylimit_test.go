@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/config"
+	"producer/internal/handler"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bookService := handler.NewMockBookService(ctrl)
+
+	body := `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`
+	large := strings.Replace(body, "123", "1234", 1)
+
+	router := gin.Default()
+	router.POST("/books", BodyLimit(int64(len(body))), handler.New(bookService, config.Books{}).Post)
+
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		expectCode int
+	}{
+		{name: "body within limit", body: body, expectCode: http.StatusCreated},
+		{name: "declared body over limit", body: large, expectCode: http.StatusRequestEntityTooLarge},
+		{name: "chunked body over limit", body: large, chunked: true, expectCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectCode == http.StatusCreated {
+				bookService.EXPECT().Post(gomock.Any(), gomock.Any()).Return(uuid.New().String(), nil).Times(1)
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/books", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectCode {
+				t.Errorf("expect code %d, but got %d", tt.expectCode, w.Code)
+			}
+			if tt.expectCode == http.StatusRequestEntityTooLarge && !strings.Contains(w.Body.String(), string(handler.CodeBodyTooLarge)) {
+				t.Errorf("expect %s error, but got %s", handler.CodeBodyTooLarge, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"producer/internal/auth"
+	"producer/internal/handler"
+	"producer/internal/storage"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyStore remembers responses by idempotency key. Reserve must
+// atomically either create an in-progress record for the key and return true,
+// or return the record already stored under the key.
+type IdempotencyStore interface {
+	Reserve(key string, fingerprint string) (storage.IdempotencyRecord, bool)
+	Complete(key string, record storage.IdempotencyRecord)
+	Release(key string)
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency holds the request body in memory to fingerprint it, bodies over
+// maxBodySize bytes are rejected. Zero maxBodySize disables the check.
+func Idempotency(store IdempotencyStore, maxBodySize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, &handler.APIError{
+				Code:    handler.CodeIdempotencyKeyTooLong,
+				Message: "idempotency key is too long",
+			})
+			return
+		}
+
+		// keys of different clients must not collide
+		if client, ok := auth.ClientFromContext(c.Request.Context()); ok {
+			key = client.Key + ":" + key
+		}
+
+		if maxBodySize > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, handler.BodyTooLarge(maxBytesError.Limit))
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, &handler.APIError{
+				Code:    handler.CodeInvalidBody,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c.Request, body)
+
+		record, reserved := store.Reserve(key, fingerprint)
+		if !reserved {
+			switch {
+			case record.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusConflict, &handler.APIError{
+					Code:    handler.CodeIdempotencyKeyMismatch,
+					Message: "idempotency key is already used for a different request",
+				})
+			case !record.Completed:
+				c.AbortWithStatusJSON(http.StatusConflict, &handler.APIError{
+					Code:    handler.CodeIdempotencyKeyInProgress,
+					Message: "request with this idempotency key is in progress",
+				})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(record.StatusCode, record.ContentType, record.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				store.Release(key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		if retryable(recorder.Status()) {
+			return
+		}
+
+		store.Complete(key, storage.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		completed = true
+	}
+}
+
+// retryable tells responses after which the client may retry with the same
+// key: exceeded quotas and failures that handed nothing to Kafka. A timed out
+// delivery may still succeed, so it is kept and replayed instead of producing
+// the book again.
+func retryable(status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return true
+	case status == http.StatusGatewayTimeout:
+		return false
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
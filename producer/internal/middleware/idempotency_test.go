@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/storage/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	fail := 0
+
+	router := gin.Default()
+	router.POST("/books", Idempotency(memory.NewIdempotencyStorage(time.Hour), 1<<10), func(c *gin.Context) {
+		calls++
+		if fail != 0 {
+			c.JSON(fail, gin.H{"error": "produce book error"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": uuid.New().String()})
+	})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/books", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"123"}`
+
+	first := post("key-1", body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expect code %d, but got %d", http.StatusCreated, first.Code)
+	}
+
+	replay := post("key-1", body)
+	if replay.Code != http.StatusCreated {
+		t.Fatalf("expect replayed code %d, but got %d", http.StatusCreated, replay.Code)
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("expect replayed body %s, but got %s", first.Body.String(), replay.Body.String())
+	}
+	if replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expect %s header on replay", IdempotentReplayedHeader)
+	}
+	if calls != 1 {
+		t.Errorf("expect handler to be called once, but got %d", calls)
+	}
+
+	conflict := post("key-1", `{"title":"Anna Karenina", "authors":["Lev Tolstoi"], "text":"123"}`)
+	if conflict.Code != http.StatusConflict {
+		t.Errorf("expect code %d, but got %d", http.StatusConflict, conflict.Code)
+	}
+
+	post("", body)
+	post("", body)
+	if calls != 3 {
+		t.Errorf("expect requests without key to reach handler, but got %d calls", calls)
+	}
+
+	fail = http.StatusInternalServerError
+	if w := post("key-2", body); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expect code %d, but got %d", http.StatusInternalServerError, w.Code)
+	}
+	fail = 0
+	if w := post("key-2", body); w.Code != http.StatusCreated {
+		t.Errorf("expect failed request to be retryable, but got code %d", w.Code)
+	}
+
+	// the book may still be delivered, a retry must not produce it again
+	fail = http.StatusGatewayTimeout
+	if w := post("key-4", body); w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expect code %d, but got %d", http.StatusGatewayTimeout, w.Code)
+	}
+	fail = 0
+	if w := post("key-4", body); w.Code != http.StatusGatewayTimeout || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expect timed out request to be replayed, but got code %d", w.Code)
+	}
+
+	large := `{"title":"War and Peace", "authors":["Lev Tolstoi"], "text":"` + strings.Repeat("1", 1<<10) + `"}`
+	if w := post("key-3", large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expect code %d, but got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	if calls != 6 {
+		t.Errorf("expect too large request not to reach handler, but got %d calls", calls)
+	}
+}
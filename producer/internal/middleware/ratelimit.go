@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"log/slog"
+	"math"
+	"net/http"
+	"producer/internal/auth"
+	"producer/internal/config"
+	"producer/internal/handler"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	cfg       config.RateLimit
+	lastSweep time.Time
+}
+
+// reserve takes a token from the client's bucket and returns how long the
+// client has to wait when the bucket is empty.
+func (l *rateLimiter) reserve(client string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.cfg.Rate), l.cfg.Burst)}
+		l.buckets[client] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Second
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.cfg.TTL {
+		return
+	}
+
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.cfg.TTL {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit limits requests of every API key, or of every IP for
+// unauthenticated requests, with a token bucket.
+func RateLimit(cfg config.RateLimit) gin.HandlerFunc {
+	return rateLimit(cfg, func(c *gin.Context) string {
+		if authClient, ok := auth.ClientFromContext(c.Request.Context()); ok {
+			return "key:" + authClient.Key
+		}
+		return "ip:" + c.ClientIP()
+	})
+}
+
+// IPRateLimit limits requests of every IP whatever key they present. It goes
+// before authentication, so guessing keys is limited too. The IP is taken from
+// forwarding headers of trusted proxies only.
+func IPRateLimit(cfg config.RateLimit) gin.HandlerFunc {
+	return rateLimit(cfg, func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	})
+}
+
+func rateLimit(cfg config.RateLimit, clientOf func(c *gin.Context) string) gin.HandlerFunc {
+	if cfg.Rate <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	limiter := &rateLimiter{
+		buckets:   make(map[string]*bucket),
+		cfg:       cfg,
+		lastSweep: time.Now(),
+	}
+
+	return func(c *gin.Context) {
+		client := clientOf(c)
+
+		delay := limiter.reserve(client, time.Now())
+		if delay <= 0 {
+			c.Next()
+			return
+		}
+
+		slog.Warn("request is shed",
+			slog.String("reason", "rate limit"),
+			slog.String("client", client),
+			slog.String("path", c.FullPath()))
+
+		setRetryAfter(c, delay)
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, &handler.APIError{
+			Code:    handler.CodeRateLimited,
+			Message: "too many requests",
+		})
+	}
+}
+
+// ConcurrencyLimit sheds requests above limit requests in flight, zero
+// limit disables it.
+func ConcurrencyLimit(limit int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	inFlight := make(chan struct{}, limit)
+
+	return func(c *gin.Context) {
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			slog.Warn("request is shed",
+				slog.String("reason", "concurrency limit"),
+				slog.String("client", c.ClientIP()),
+				slog.String("path", c.FullPath()))
+
+			setRetryAfter(c, time.Second)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, &handler.APIError{
+				Code:    handler.CodeOverloaded,
+				Message: "server is overloaded",
+			})
+			return
+		}
+		defer func() { <-inFlight }()
+
+		c.Next()
+	}
+}
+
+func setRetryAfter(c *gin.Context, delay time.Duration) {
+	seconds := int64(math.Ceil(delay.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"producer/internal/config"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	router := gin.Default()
+	router.GET("/books", RateLimit(config.RateLimit{Rate: 1, Burst: 2, TTL: time.Minute}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/books", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("expect burst request %d to pass, but got %d", i, w.Code)
+		}
+	}
+
+	w := get("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect code %d, but got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expect Retry-After 1, but got %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := get("10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("expect other clients to have own buckets, but got %d", w.Code)
+	}
+}
+
+func TestIPRateLimit(t *testing.T) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("failed to set trusted proxies: %s", err)
+	}
+	router.GET("/books", IPRateLimit(config.RateLimit{Rate: 1, Burst: 2, TTL: time.Minute}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// neither a forged forwarding header nor a new key gets a fresh bucket
+	get := func(forwardedFor, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/books", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set(APIKeyHeader, key)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get("192.168.0."+strconv.Itoa(i), "key-"+strconv.Itoa(i)); w.Code != http.StatusOK {
+			t.Fatalf("expect burst request %d to pass, but got %d", i, w.Code)
+		}
+	}
+	if w := get("192.168.0.2", "key-2"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect code %d, but got %d", http.StatusTooManyRequests, w.Code)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.Default()
+	router.GET("/books", ConcurrencyLimit(1), func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/books", nil)
+		router.ServeHTTP(w, req)
+		done <- w.Code
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/books", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expect shed request with code %d, but got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expect first request to pass, but got %d", code)
+	}
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"producer/internal/entity"
+)
+
+const (
+	ContentTypeHeader   = "content-type"
+	SchemaVersionHeader = "schema-version"
+	ClientHeader        = "client"
+
+	ContentTypeProtobuf = "application/x-protobuf"
+	BookSchemaVersion   = "2"
+)
+
+var eventTypes = map[entity.EventType]bookv1.EventType{
+	entity.EventCreated: bookv1.EventType_EVENT_TYPE_CREATED,
+	entity.EventUpdated: bookv1.EventType_EVENT_TYPE_UPDATED,
+	entity.EventDeleted: bookv1.EventType_EVENT_TYPE_DELETED,
+}
+
+func encodeEvent(e entity.BookEvent) ([]byte, []kafka.Header, error) {
+	eventType, ok := eventTypes[e.Type]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown event type %q", e.Type)
+	}
+
+	book := e.Book
+	event := &bookv1.BookEvent{
+		Id:         book.Id,
+		Title:      book.Title,
+		Authors:    book.Authors,
+		Text:       book.Text,
+		Type:       eventType,
+		UpdateMask: e.Fields,
+	}
+	if book.TextRef != nil {
+		event.TextRef = &bookv1.TextRef{
+			Key:    book.TextRef.Key,
+			Sha256: book.TextRef.Sha256,
+			Size:   book.TextRef.Size,
+		}
+	}
+
+	value, err := proto.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := []kafka.Header{
+		{Key: ContentTypeHeader, Value: []byte(ContentTypeProtobuf)},
+		{Key: SchemaVersionHeader, Value: []byte(BookSchemaVersion)},
+	}
+	if e.Client != "" {
+		headers = append(headers, kafka.Header{Key: ClientHeader, Value: []byte(e.Client)})
+	}
+
+	return value, headers, nil
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	bookv1 "github.com/s-khechnev/pet-project/protos/gen/go/book"
+	"google.golang.org/protobuf/proto"
+	"producer/internal/entity"
+	"slices"
+	"testing"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		event         entity.BookEvent
+		expectType    bookv1.EventType
+		expectHeaders map[string]string
+		expectErr     bool
+	}{
+		{
+			name: "created",
+			event: entity.BookEvent{
+				Type: entity.EventCreated,
+				Book: entity.Book{Id: "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11", Title: "War and Peace", Authors: []string{"Lev Tolstoi"}, Text: "123"},
+			},
+			expectType: bookv1.EventType_EVENT_TYPE_CREATED,
+			expectHeaders: map[string]string{
+				ContentTypeHeader:   ContentTypeProtobuf,
+				SchemaVersionHeader: BookSchemaVersion,
+			},
+		},
+		{
+			name: "update of a client",
+			event: entity.BookEvent{
+				Type:   entity.EventUpdated,
+				Book:   entity.Book{Id: "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11", Title: "War and Peace"},
+				Fields: []string{entity.FieldTitle},
+				Client: "library",
+			},
+			expectType: bookv1.EventType_EVENT_TYPE_UPDATED,
+			expectHeaders: map[string]string{
+				ContentTypeHeader:   ContentTypeProtobuf,
+				SchemaVersionHeader: BookSchemaVersion,
+				ClientHeader:        "library",
+			},
+		},
+		{
+			name: "text in the blob store",
+			event: entity.BookEvent{
+				Type: entity.EventCreated,
+				Book: entity.Book{
+					Id:      "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11",
+					Title:   "War and Peace",
+					TextRef: &entity.TextRef{Key: "ab/cdef", Sha256: "abcdef", Size: 3},
+				},
+			},
+			expectType: bookv1.EventType_EVENT_TYPE_CREATED,
+			expectHeaders: map[string]string{
+				ContentTypeHeader:   ContentTypeProtobuf,
+				SchemaVersionHeader: BookSchemaVersion,
+			},
+		},
+		{
+			name:      "unknown type",
+			event:     entity.BookEvent{Type: "archived"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, headers, err := encodeEvent(tt.event)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expect error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to encode event: %s", err)
+			}
+
+			if len(headers) != len(tt.expectHeaders) {
+				t.Errorf("expect headers %v, but got %v", tt.expectHeaders, headers)
+			}
+			for _, h := range headers {
+				if expect, ok := tt.expectHeaders[h.Key]; !ok || string(h.Value) != expect {
+					t.Errorf("expect header %s to be %q, but got %q", h.Key, expect, h.Value)
+				}
+			}
+
+			var event bookv1.BookEvent
+			if err := proto.Unmarshal(value, &event); err != nil {
+				t.Fatalf("failed to unmarshal event: %s", err)
+			}
+			book := tt.event.Book
+			if event.GetType() != tt.expectType || event.GetId() != book.Id || event.GetTitle() != book.Title ||
+				!slices.Equal(event.GetUpdateMask(), tt.event.Fields) {
+				t.Errorf("unexpected event: %v", &event)
+			}
+			if ref := book.TextRef; ref != nil && (event.GetTextRef().GetKey() != ref.Key || event.GetTextRef().GetSize() != ref.Size) {
+				t.Errorf("expect text ref %+v, but got %v", ref, event.GetTextRef())
+			}
+		})
+	}
+}
@@ -1,73 +1,283 @@
 package queue
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/s-khechnev/pet-project/protos/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"producer/internal/entity"
+	"producer/internal/metrics"
+	"sync/atomic"
 	"time"
 )
 
+type BookStatusTracker interface {
+	SetStatus(status entity.BookStatus)
+}
+
+type DeliveryMode string
+
+const (
+	// DeliveryModeAsync returns from Produce as soon as the message is enqueued.
+	DeliveryModeAsync DeliveryMode = "async"
+	// DeliveryModeSync returns from Produce only after the broker acknowledged the message.
+	DeliveryModeSync DeliveryMode = "sync"
+)
+
 type KafkaProducer struct {
-	producer     *kafka.Producer
-	topic        string
-	flushTimeout int
+	producer        *kafka.Producer
+	topic           string
+	flushTimeout    int
+	statusTracker   BookStatusTracker
+	deliveryMode    DeliveryMode
+	deliveryTimeout time.Duration
+	spool           BookSpool
+	unavailable     atomic.Bool
 }
 
-func NewKafkaProducer(topic string, config *kafka.ConfigMap, flushTimeout int) (*KafkaProducer, error) {
+type deliveryWaiter struct {
+	delivered chan error
+	spooled   bool
+}
+
+func NewKafkaProducer(
+	topic string,
+	config *kafka.ConfigMap,
+	flushTimeout int,
+	statusTracker BookStatusTracker,
+	deliveryMode DeliveryMode,
+	deliveryTimeout time.Duration,
+	spool BookSpool,
+) (*KafkaProducer, error) {
+	switch deliveryMode {
+	case DeliveryModeAsync, DeliveryModeSync:
+	case "":
+		deliveryMode = DeliveryModeAsync
+	default:
+		return nil, fmt.Errorf("unknown delivery mode %q", deliveryMode)
+	}
+
 	producer, err := kafka.NewProducer(config)
 	if err != nil {
 		return nil, err
 	}
 
-	go func() {
-		for e := range producer.Events() {
-			switch ev := e.(type) {
-			case *kafka.Message:
-				m := ev
-				if m.TopicPartition.Error != nil {
-					slog.Error("failed to deliver book", slog.String("error", m.TopicPartition.Error.Error()))
-				} else {
-					slog.Info("delivered book",
-						slog.String("topic", *m.TopicPartition.Topic),
-						slog.Int("partition", int(m.TopicPartition.Partition)),
-						slog.Int("offset", int(m.TopicPartition.Offset)),
-						slog.String("book_id", string(m.Key)))
-				}
-			case kafka.Error:
-				slog.Error("kafka error", slog.String("error", ev.Error()))
+	p := &KafkaProducer{
+		producer:        producer,
+		topic:           topic,
+		flushTimeout:    flushTimeout,
+		statusTracker:   statusTracker,
+		deliveryMode:    deliveryMode,
+		deliveryTimeout: deliveryTimeout,
+		spool:           spool,
+	}
+
+	go p.handleEvents()
+
+	return p, nil
+}
+
+func (p *KafkaProducer) handleEvents() {
+	for e := range p.producer.Events() {
+		switch ev := e.(type) {
+		case *kafka.Message:
+			p.handleDeliveryReport(ev)
+		case kafka.Error:
+			slog.Error("kafka error", slog.String("error", ev.Error()))
+			if ev.Code() == kafka.ErrAllBrokersDown {
+				p.unavailable.Store(true)
 			}
 		}
-	}()
+	}
+}
+
+func (p *KafkaProducer) handleDeliveryReport(m *kafka.Message) {
+	id := string(m.Key)
 
-	return &KafkaProducer{
-		producer:     producer,
-		topic:        topic,
-		flushTimeout: flushTimeout,
-	}, nil
+	waiter, _ := m.Opaque.(*deliveryWaiter)
+	if waiter != nil {
+		waiter.delivered <- m.TopicPartition.Error
+	}
+
+	if m.TopicPartition.Error != nil {
+		metrics.Deliveries.WithLabelValues(metrics.ResultFailure).Inc()
+		slog.Error("failed to deliver book",
+			slog.String("book_id", id),
+			slog.String("error", m.TopicPartition.Error.Error()))
+
+		switch {
+		case waiter != nil && waiter.spooled:
+			// stays in the spool and is retried by the forwarder
+			return
+		case waiter == nil && p.spool != nil && isKafkaUnavailable(m.TopicPartition.Error):
+			if err := p.spoolMessage(m, m.TopicPartition.Error.Error()); err == nil {
+				return
+			}
+		}
+
+		p.statusTracker.SetStatus(entity.BookStatus{
+			Id:        id,
+			Status:    entity.StatusFailed,
+			Reason:    "delivery failed: " + m.TopicPartition.Error.Error(),
+			UpdatedAt: time.Now(),
+		})
+		return
+	}
+
+	p.unavailable.Store(false)
+
+	metrics.Deliveries.WithLabelValues(metrics.ResultSuccess).Inc()
+	metrics.DeliveryLatency.Observe(time.Since(m.Timestamp).Seconds())
+
+	slog.Info("delivered book",
+		slog.String("topic", *m.TopicPartition.Topic),
+		slog.Int("partition", int(m.TopicPartition.Partition)),
+		slog.Int("offset", int(m.TopicPartition.Offset)),
+		slog.String("book_id", id))
+
+	p.statusTracker.SetStatus(entity.BookStatus{
+		Id:        id,
+		Status:    entity.StatusDelivered,
+		UpdatedAt: time.Now(),
+	})
 }
 
-var ErrJsonMarshaling = errors.New("marshaling error")
+const tracerName = "producer/internal/queue"
+
+var (
+	ErrMarshaling      = errors.New("marshaling error")
+	ErrDeliveryFailed  = errors.New("delivery failed")
+	ErrDeliveryTimeout = errors.New("delivery timeout")
+)
+
+func (p *KafkaProducer) Produce(ctx context.Context, event entity.BookEvent) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, p.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(p.topic),
+			semconv.MessagingKafkaMessageKey(event.Book.Id),
+			attribute.String("book.event", string(event.Type)),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
-func (p *KafkaProducer) Produce(book entity.Book) error {
-	bookJson, err := json.Marshal(book)
+	value, headers, err := encodeEvent(event)
 	if err != nil {
-		slog.Error("failed marshal book", slog.String("error", err.Error()))
-		return ErrJsonMarshaling
+		slog.Error("failed marshal book event", slog.String("error", err.Error()))
+		return ErrMarshaling
 	}
+	otel.GetTextMapPropagator().Inject(ctx, tracing.NewHeaderCarrier(&headers))
 
-	err = p.producer.Produce(&kafka.Message{
+	msg := &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
-		Key:            []byte(book.Id),
-		Value:          bookJson,
+		Key:            []byte(event.Book.Id),
+		Value:          value,
+		Headers:        headers,
 		Timestamp:      time.Now(),
-	}, nil)
-	if err != nil {
+	}
+
+	if p.deliveryMode == DeliveryModeAsync {
+		// keep spooled books ahead of new ones until the spool is drained
+		if p.spool != nil && (p.unavailable.Load() || p.spool.Depth() > 0) {
+			return p.spoolMessage(msg, "kafka is unavailable")
+		}
+
+		if err := p.enqueue(msg); err != nil {
+			if p.spool != nil && isKafkaUnavailable(err) {
+				return p.spoolMessage(msg, err.Error())
+			}
+			slog.Error("failed produce book", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	}
+
+	return p.produceAndWait(ctx, msg, false)
+}
+
+func (p *KafkaProducer) produceAndWait(ctx context.Context, msg *kafka.Message, spooled bool) error {
+	if _, ok := ctx.Deadline(); !ok && p.deliveryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.deliveryTimeout)
+		defer cancel()
+	}
+
+	// the delivery report still goes through the events goroutine, so the
+	// channel must be buffered: nobody reads it once the deadline has passed
+	waiter := &deliveryWaiter{
+		delivered: make(chan error, 1),
+		spooled:   spooled,
+	}
+	msg.Opaque = waiter
+
+	if err := p.enqueue(msg); err != nil {
 		slog.Error("failed produce book", slog.String("error", err.Error()))
 		return err
 	}
 
+	select {
+	case err := <-waiter.delivered:
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrDeliveryFailed, err)
+		}
+		return nil
+	case <-ctx.Done():
+		slog.Warn("book is not acknowledged in time", slog.String("book_id", string(msg.Key)))
+		return fmt.Errorf("%w: %w", ErrDeliveryTimeout, ctx.Err())
+	}
+}
+
+func (p *KafkaProducer) enqueue(msg *kafka.Message) error {
+	if err := p.producer.Produce(msg, nil); err != nil {
+		metrics.ProduceAttempts.WithLabelValues(metrics.ResultRejected).Inc()
+		return err
+	}
+
+	metrics.ProduceAttempts.WithLabelValues(metrics.ResultEnqueued).Inc()
+	return nil
+}
+
+// Len is the number of messages in the local queue waiting for delivery.
+func (p *KafkaProducer) Len() int {
+	return p.producer.Len()
+}
+
+// Ping checks that the book topic metadata can be fetched from the brokers.
+func (p *KafkaProducer) Ping(ctx context.Context) error {
+	timeout := time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+
+	metadata, err := p.producer.GetMetadata(&p.topic, false, int(timeout.Milliseconds()))
+	if err != nil {
+		return fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	topic, ok := metadata.Topics[p.topic]
+	if !ok {
+		return fmt.Errorf("topic %s is not found", p.topic)
+	}
+	if topic.Error.Code() != kafka.ErrNoError {
+		return fmt.Errorf("topic %s: %w", p.topic, topic.Error)
+	}
+
 	return nil
 }
 
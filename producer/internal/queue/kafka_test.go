@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"producer/internal/entity"
+	"producer/internal/spool"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTopic = "books"
+
+type recordingTracker struct {
+	mu       sync.Mutex
+	statuses map[string]entity.Status
+}
+
+func (t *recordingTracker) SetStatus(status entity.BookStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statuses[status.Id] = status.Status
+}
+
+func (t *recordingTracker) status(id string) entity.Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statuses[id]
+}
+
+// newMockProducer starts an in-process librdkafka cluster of one broker with
+// the book topic.
+func newMockProducer(t *testing.T, config kafka.ConfigMap, mode DeliveryMode, deliveryTimeout time.Duration, bookSpool BookSpool) (*KafkaProducer, *kafka.MockCluster, *recordingTracker) {
+	t.Helper()
+
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("failed to start mock cluster: %s", err)
+	}
+	t.Cleanup(cluster.Close)
+	if err := cluster.CreateTopic(testTopic, 1, 1); err != nil {
+		t.Fatalf("failed to create topic: %s", err)
+	}
+
+	config["bootstrap.servers"] = cluster.BootstrapServers()
+	tracker := &recordingTracker{statuses: make(map[string]entity.Status)}
+	producer, err := NewKafkaProducer(testTopic, &config, 100, tracker, mode, deliveryTimeout, bookSpool)
+	if err != nil {
+		t.Fatalf("failed to create producer: %s", err)
+	}
+	t.Cleanup(producer.Close)
+
+	return producer, cluster, tracker
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProduceSync(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          kafka.ConfigMap
+		brokerDown      bool
+		deliveryTimeout time.Duration
+		expectErr       error
+		expectStatus    entity.Status
+	}{
+		{
+			name:            "delivered",
+			config:          kafka.ConfigMap{},
+			deliveryTimeout: 5 * time.Second,
+			expectStatus:    entity.StatusDelivered,
+		},
+		{
+			name:            "failed",
+			config:          kafka.ConfigMap{"message.timeout.ms": 100},
+			brokerDown:      true,
+			deliveryTimeout: 5 * time.Second,
+			expectErr:       ErrDeliveryFailed,
+			expectStatus:    entity.StatusFailed,
+		},
+		{
+			// the late delivery report must still reach the status
+			name:            "timeout",
+			config:          kafka.ConfigMap{"message.timeout.ms": 5000},
+			brokerDown:      true,
+			deliveryTimeout: 50 * time.Millisecond,
+			expectErr:       ErrDeliveryTimeout,
+			expectStatus:    entity.StatusDelivered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer, cluster, tracker := newMockProducer(t, tt.config, DeliveryModeSync, tt.deliveryTimeout, nil)
+			if tt.brokerDown {
+				if err := cluster.SetBrokerDown(1); err != nil {
+					t.Fatalf("failed to stop broker: %s", err)
+				}
+			}
+
+			id := "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11"
+			err := producer.Produce(context.Background(), entity.BookEvent{
+				Type: entity.EventCreated,
+				Book: entity.Book{Id: id, Title: "War and Peace"},
+			})
+			if tt.expectErr == nil && err != nil {
+				t.Fatalf("expect book to be delivered, but got %s", err)
+			}
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expect error %v, but got %v", tt.expectErr, err)
+			}
+
+			if tt.brokerDown {
+				if err := cluster.SetBrokerUp(1); err != nil {
+					t.Fatalf("failed to start broker: %s", err)
+				}
+			}
+			eventually(t, "status "+string(tt.expectStatus), func() bool {
+				return tracker.status(id) == tt.expectStatus
+			})
+		})
+	}
+}
+
+func TestSpoolForwarding(t *testing.T) {
+	bookSpool, err := spool.Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("failed to open spool: %s", err)
+	}
+	defer bookSpool.Close()
+
+	producer, cluster, tracker := newMockProducer(t, kafka.ConfigMap{"message.timeout.ms": 100}, DeliveryModeAsync, 5*time.Second, bookSpool)
+	if err := cluster.SetBrokerDown(1); err != nil {
+		t.Fatalf("failed to stop broker: %s", err)
+	}
+
+	id := "5b4fbc5e-9a2c-4d4f-8a8e-1d7d1e6f5c11"
+	err = producer.Produce(context.Background(), entity.BookEvent{
+		Type: entity.EventCreated,
+		Book: entity.Book{Id: id, Title: "War and Peace"},
+	})
+	if err != nil {
+		t.Fatalf("expect book to be enqueued, but got %s", err)
+	}
+
+	// the failed delivery is spooled instead of failing the book
+	eventually(t, "book to be spooled", func() bool {
+		return bookSpool.Depth() == 1
+	})
+	if status := tracker.status(id); status != entity.StatusAccepted {
+		t.Errorf("expect spooled book to stay %s, but got %s", entity.StatusAccepted, status)
+	}
+
+	if err := cluster.SetBrokerUp(1); err != nil {
+		t.Fatalf("failed to start broker: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		producer.ForwardSpool(ctx, 10*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-forwarded
+	}()
+
+	eventually(t, "spool to be drained", func() bool {
+		return bookSpool.Depth() == 0 && tracker.status(id) == entity.StatusDelivered
+	})
+}
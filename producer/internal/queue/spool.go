@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"producer/internal/entity"
+	"producer/internal/metrics"
+	"producer/internal/spool"
+	"time"
+)
+
+type BookSpool interface {
+	Append(record spool.Record) error
+	Next() (spool.Record, error)
+	Ack() error
+	Depth() int
+	Notify() <-chan struct{}
+}
+
+func isKafkaUnavailable(err error) bool {
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) {
+		return false
+	}
+
+	switch kafkaErr.Code() {
+	case kafka.ErrQueueFull, kafka.ErrAllBrokersDown, kafka.ErrTransport, kafka.ErrMsgTimedOut:
+		return true
+	default:
+		return kafkaErr.IsRetriable()
+	}
+}
+
+func (p *KafkaProducer) spoolMessage(msg *kafka.Message, reason string) error {
+	record := spool.Record{
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Timestamp: msg.Timestamp,
+	}
+	for _, h := range msg.Headers {
+		record.Headers = append(record.Headers, spool.Header{Key: h.Key, Value: h.Value})
+	}
+
+	if err := p.spool.Append(record); err != nil {
+		slog.Error("failed to spool book", slog.String("book_id", string(msg.Key)), slog.String("error", err.Error()))
+		return err
+	}
+
+	metrics.ProduceAttempts.WithLabelValues(metrics.ResultSpooled).Inc()
+	slog.Warn("book is spooled",
+		slog.String("book_id", string(msg.Key)),
+		slog.String("reason", reason),
+		slog.Int("spool_depth", p.spool.Depth()))
+
+	p.statusTracker.SetStatus(entity.BookStatus{
+		Id:        string(msg.Key),
+		Status:    entity.StatusAccepted,
+		Reason:    "spooled: " + reason,
+		UpdatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+// ForwardSpool drains the spool to Kafka in order, waiting for every message
+// to be acknowledged before moving to the next one.
+func (p *KafkaProducer) ForwardSpool(ctx context.Context, retryInterval time.Duration) {
+	slog.Info("spool forwarder started", slog.Int("spool_depth", p.spool.Depth()))
+
+	for {
+		err := p.forwardNext(ctx)
+		switch {
+		case err == nil:
+			if p.spool.Depth() == 0 {
+				slog.Info("spool is drained")
+			}
+			continue
+		case errors.Is(err, spool.ErrEmpty):
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.spool.Notify():
+			}
+			continue
+		}
+
+		slog.Warn("failed to forward spooled book",
+			slog.String("error", err.Error()),
+			slog.Int("spool_depth", p.spool.Depth()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (p *KafkaProducer) forwardNext(ctx context.Context) error {
+	record, err := p.spool.Next()
+	if errors.Is(err, spool.ErrCorrupt) {
+		metrics.SpoolSkipped.Inc()
+		slog.Error("dropping corrupted spool record", slog.String("error", err.Error()))
+		return p.spool.Ack()
+	}
+	if err != nil {
+		return err
+	}
+
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
+		Key:            record.Key,
+		Value:          record.Value,
+		Timestamp:      record.Timestamp,
+	}
+	for _, h := range record.Headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: h.Key, Value: h.Value})
+	}
+
+	if err := p.produceAndWait(ctx, msg, true); err != nil {
+		return err
+	}
+
+	p.unavailable.Store(false)
+
+	return p.spool.Ack()
+}
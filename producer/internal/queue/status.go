@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"producer/internal/entity"
+	"time"
+)
+
+type StatusConsumer struct {
+	consumer      *kafka.Consumer
+	statusTracker BookStatusTracker
+	context       context.Context
+	timeoutOnPoll time.Duration
+	done          chan struct{}
+}
+
+func NewStatusConsumer(
+	ctx context.Context,
+	statusTracker BookStatusTracker,
+	topic string,
+	groupID string,
+	config *kafka.ConfigMap,
+	timeoutOnPoll time.Duration,
+) (*StatusConsumer, error) {
+	err := config.SetKey("group.id", groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := kafka.NewConsumer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Subscribe(topic, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusConsumer{
+		consumer:      c,
+		statusTracker: statusTracker,
+		context:       ctx,
+		timeoutOnPoll: timeoutOnPoll,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+func (c *StatusConsumer) Run() {
+	slog.Info("status consumer started")
+	defer close(c.done)
+
+	for {
+		select {
+		case <-c.context.Done():
+			return
+		default:
+		}
+
+		ev := c.consumer.Poll(int(c.timeoutOnPoll.Milliseconds()))
+		if ev == nil {
+			continue
+		}
+
+		switch e := ev.(type) {
+		case *kafka.Message:
+			var status entity.BookStatus
+			if err := json.Unmarshal(e.Value, &status); err != nil {
+				slog.Error("failed unmarshalling book status from json", slog.String("error", err.Error()))
+				continue
+			}
+
+			c.statusTracker.SetStatus(status)
+		case kafka.Error:
+			slog.Error("status consumer error", slog.String("error", e.Error()))
+		default:
+		}
+	}
+}
+
+// Close must be called after the context passed to NewStatusConsumer is
+// cancelled: it waits for Run to leave the poll loop.
+func (c *StatusConsumer) Close() error {
+	<-c.done
+	return c.consumer.Close()
+}
@@ -1,31 +1,82 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"log/slog"
+	"producer/internal/auth"
+	"producer/internal/config"
 	"producer/internal/entity"
 	"producer/internal/handler"
+	"producer/internal/queue"
+	"producer/internal/storage"
+	"time"
 )
 
 type BookProducer interface {
-	Produce(book entity.Book) error
+	Produce(ctx context.Context, event entity.BookEvent) error
+}
+
+type BookStatusStorage interface {
+	SetStatus(status entity.BookStatus)
+	GetStatus(id string) (entity.BookStatus, error)
+}
+
+type TextStore interface {
+	Put(data []byte) (entity.TextRef, error)
+}
+
+type QuotaStorage interface {
+	Consume(key string, quota config.Quota, books int64, bytes int64) error
+	Refund(key string, books int64, bytes int64, consumedAt time.Time)
 }
 
 type BookService struct {
-	bookProducer BookProducer
+	bookProducer    BookProducer
+	statusStorage   BookStatusStorage
+	textStore       TextStore
+	quotaStorage    QuotaStorage
+	inlineTextLimit int
 }
 
-func New(producer BookProducer) *BookService {
+func New(
+	producer BookProducer,
+	statusStorage BookStatusStorage,
+	textStore TextStore,
+	quotaStorage QuotaStorage,
+	inlineTextLimit int,
+) *BookService {
 	return &BookService{
-		bookProducer: producer,
+		bookProducer:    producer,
+		statusStorage:   statusStorage,
+		textStore:       textStore,
+		quotaStorage:    quotaStorage,
+		inlineTextLimit: inlineTextLimit,
 	}
 }
 
-var ErrProduceBook = errors.New("produce book error")
+var (
+	ErrProduceBook = errors.New("produce book error")
+	ErrStoreText   = errors.New("store book text error")
+)
 
-func (s *BookService) Post(req handler.PostBookRequest) (string, error) {
-	id := uuid.New().String()
+func (s *BookService) Post(ctx context.Context, req handler.PostBookRequest) (string, error) {
+	book := entity.Book{
+		Id:      uuid.New().String(),
+		Title:   req.Title,
+		Authors: req.Authors,
+		Text:    req.Text,
+	}
+
+	if err := s.produce(ctx, entity.BookEvent{Type: entity.EventCreated, Book: book}); err != nil {
+		return uuid.Nil.String(), err
+	}
+
+	return book.Id, nil
+}
+
+func (s *BookService) Put(ctx context.Context, id string, req handler.PostBookRequest) error {
 	book := entity.Book{
 		Id:      id,
 		Title:   req.Title,
@@ -33,10 +84,102 @@ func (s *BookService) Post(req handler.PostBookRequest) (string, error) {
 		Text:    req.Text,
 	}
 
-	if err := s.bookProducer.Produce(book); err != nil {
+	return s.produce(ctx, entity.BookEvent{Type: entity.EventUpdated, Book: book})
+}
+
+func (s *BookService) Patch(ctx context.Context, id string, req handler.PatchBookRequest) error {
+	event := entity.BookEvent{
+		Type: entity.EventUpdated,
+		Book: entity.Book{Id: id},
+	}
+	if req.Title != nil {
+		event.Book.Title = *req.Title
+		event.Fields = append(event.Fields, entity.FieldTitle)
+	}
+	if req.Authors != nil {
+		event.Book.Authors = *req.Authors
+		event.Fields = append(event.Fields, entity.FieldAuthors)
+	}
+	if req.Text != nil {
+		event.Book.Text = *req.Text
+		event.Fields = append(event.Fields, entity.FieldText)
+	}
+
+	return s.produce(ctx, event)
+}
+
+func (s *BookService) Delete(ctx context.Context, id string) error {
+	return s.produce(ctx, entity.BookEvent{Type: entity.EventDeleted, Book: entity.Book{Id: id}})
+}
+
+func (s *BookService) produce(ctx context.Context, event entity.BookEvent) (err error) {
+	book := &event.Book
+
+	if client, ok := auth.ClientFromContext(ctx); ok {
+		event.Client = client.Owner
+
+		// deletes carry no book, so they are not accounted
+		if event.Type != entity.EventDeleted {
+			books, bytes, consumedAt := int64(1), int64(len(book.Text)), time.Now()
+			if err := s.quotaStorage.Consume(client.Key, client.Quota, books, bytes); err != nil {
+				return err
+			}
+			// only books handed to Kafka count against the quota
+			defer func() {
+				if err != nil {
+					s.quotaStorage.Refund(client.Key, books, bytes, consumedAt)
+				}
+			}()
+		}
+	}
+
+	if len(book.Text) > s.inlineTextLimit {
+		ref, err := s.textStore.Put([]byte(book.Text))
+		if err != nil {
+			slog.Error("failed to store book text", slog.String("error", err.Error()))
+			return ErrStoreText
+		}
+		book.Text = ""
+		book.TextRef = &ref
+	}
+
+	s.statusStorage.SetStatus(entity.BookStatus{
+		Id:        book.Id,
+		Status:    entity.StatusAccepted,
+		UpdatedAt: time.Now(),
+	})
+
+	if err := s.bookProducer.Produce(ctx, event); err != nil {
 		slog.Error("failed to push book", slog.String("error", err.Error()))
-		return uuid.Nil.String(), ErrProduceBook
+
+		switch {
+		case errors.Is(err, queue.ErrDeliveryTimeout):
+			// the message may still be delivered, its status follows the delivery report
+			return handler.ErrDeliveryTimeout
+		case errors.Is(err, queue.ErrDeliveryFailed):
+			return handler.ErrDeliveryFailed
+		}
+
+		s.statusStorage.SetStatus(entity.BookStatus{
+			Id:        book.Id,
+			Status:    entity.StatusFailed,
+			Reason:    "produce failed: " + err.Error(),
+			UpdatedAt: time.Now(),
+		})
+		return ErrProduceBook
+	}
+
+	return nil
+}
+
+func (s *BookService) Status(id string) (entity.BookStatus, error) {
+	status, err := s.statusStorage.GetStatus(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return entity.BookStatus{}, handler.ErrBookNotFound
+	}
+	if err != nil {
+		return entity.BookStatus{}, err
 	}
 
-	return id, nil
+	return status, nil
 }
@@ -0,0 +1,468 @@
+package spool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentExt  = ".seg"
+	cursorFile  = "cursor"
+	headerSize  = 8
+	maxRecordSz = 64 << 20
+)
+
+var (
+	ErrEmpty   = errors.New("spool is empty")
+	ErrCorrupt = errors.New("spool record is corrupted")
+)
+
+type Header struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type Record struct {
+	Key       []byte    `json:"key"`
+	Value     []byte    `json:"value"`
+	Headers   []Header  `json:"headers,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Spool is an on-disk write-ahead queue of Kafka messages. Records are
+// appended to segment files as [length][crc32][payload]; the read position is
+// persisted in a cursor file so that the queue survives restarts. Segments are
+// removed once they are fully read and acknowledged.
+type Spool struct {
+	mu          sync.Mutex
+	dir         string
+	segmentSize int64
+
+	writer      *os.File
+	writeSeg    uint64
+	writeOffset int64
+
+	reader     *os.File
+	readSeg    uint64
+	readOffset int64
+	nextOffset int64
+
+	depth  int
+	notify chan struct{}
+}
+
+func Open(dir string, segmentSize int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+
+	s := &Spool{
+		dir:         dir,
+		segmentSize: segmentSize,
+		notify:      make(chan struct{}, 1),
+	}
+
+	segments, err := s.listSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	readSeg, readOffset, err := s.loadCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seg := range segments {
+		if seg < readSeg {
+			if err := os.Remove(s.segmentPath(seg)); err != nil {
+				return nil, fmt.Errorf("failed to remove read segment: %w", err)
+			}
+		}
+	}
+	segments = slices.DeleteFunc(segments, func(seg uint64) bool { return seg < readSeg })
+
+	if len(segments) == 0 {
+		segments = []uint64{max(readSeg, 1)}
+		readOffset = 0
+	}
+	if readSeg < segments[0] {
+		readSeg, readOffset = segments[0], 0
+	}
+	s.readSeg, s.readOffset, s.nextOffset = readSeg, readOffset, readOffset
+
+	for i, seg := range segments {
+		from := int64(0)
+		if seg == readSeg {
+			from = readOffset
+		}
+
+		count, end, err := s.scanSegment(seg, from)
+		s.depth += count
+
+		last := i == len(segments)-1
+		torn := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		switch {
+		case err != nil && last && torn:
+			// nothing follows a record cut short by a crash
+			slog.Warn("truncating torn spool segment",
+				slog.Uint64("segment", seg),
+				slog.Int64("offset", end),
+				slog.String("error", err.Error()))
+			if err := os.Truncate(s.segmentPath(seg), end); err != nil {
+				return nil, fmt.Errorf("failed to truncate spool segment: %w", err)
+			}
+		case err != nil:
+			slog.Error("spool segment is corrupted, the rest of it will be skipped",
+				slog.Uint64("segment", seg),
+				slog.Int64("offset", end),
+				slog.String("error", err.Error()))
+		}
+
+		if last {
+			s.writeSeg, s.writeOffset = seg, end
+			if err != nil && !torn {
+				// the damaged segment is left to the reader as is
+				s.writeSeg, s.writeOffset = seg+1, 0
+			}
+		}
+	}
+
+	if err := s.openWriter(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Spool) Depth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.depth
+}
+
+// Notify is signalled after every Append.
+func (s *Spool) Notify() <-chan struct{} {
+	return s.notify
+}
+
+func (s *Spool) Append(record Record) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spool record: %w", err)
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[headerSize:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writeOffset > 0 && s.writeOffset+int64(len(buf)) > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writer.Write(buf)
+	if err != nil {
+		// drop a partly written record, the next one must start at writeOffset
+		if err := s.writer.Truncate(s.writeOffset); err != nil {
+			slog.Error("failed to truncate spool segment", slog.String("error", err.Error()))
+		}
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	if err := s.writer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	s.writeOffset += int64(n)
+	s.depth++
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Next returns the oldest record that has not been acknowledged yet. Calling
+// Next again without Ack returns the same record. ErrCorrupt means a damaged
+// record, or the rest of a segment that cannot be read, is skipped by the
+// following Ack.
+func (s *Spool) Next() (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.readSeg == s.writeSeg && s.readOffset >= s.writeOffset {
+			// records lost with a skipped segment were counted
+			s.depth = 0
+			return Record{}, ErrEmpty
+		}
+
+		if s.readSeg < s.writeSeg && s.readOffset >= s.segmentLen(s.readSeg) {
+			if err := s.advanceSegment(); err != nil {
+				return Record{}, err
+			}
+			continue
+		}
+
+		payload, size, err := s.readRecord(s.readSeg, s.readOffset)
+		if err != nil && size > 0 {
+			s.nextOffset = s.readOffset + size
+			return Record{}, err
+		}
+		if err != nil {
+			slog.Error("skipping corrupted spool segment",
+				slog.Uint64("segment", s.readSeg),
+				slog.Int64("offset", s.readOffset),
+				slog.String("error", err.Error()))
+			if s.readSeg == s.writeSeg {
+				// new records go to the next segment, this one is dropped
+				if err := s.rotate(); err != nil {
+					return Record{}, err
+				}
+			}
+			if err := s.advanceSegment(); err != nil {
+				return Record{}, err
+			}
+			return Record{}, err
+		}
+
+		s.nextOffset = s.readOffset + size
+
+		var record Record
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return Record{}, fmt.Errorf("%w: %w", ErrCorrupt, err)
+		}
+
+		return record, nil
+	}
+}
+
+// Ack marks the record returned by the last Next as forwarded. It is also
+// used to skip a record that Next reported as ErrCorrupt.
+func (s *Spool) Ack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nextOffset <= s.readOffset {
+		return nil
+	}
+
+	s.readOffset = s.nextOffset
+	// records after a damaged part of a segment are not counted
+	s.depth = max(s.depth-1, 0)
+
+	return s.saveCursor()
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reader != nil {
+		if err := s.reader.Close(); err != nil {
+			return err
+		}
+		s.reader = nil
+	}
+
+	return s.writer.Close()
+}
+
+func (s *Spool) rotate() error {
+	if err := s.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+
+	s.writeSeg++
+	s.writeOffset = 0
+
+	return s.openWriter()
+}
+
+func (s *Spool) openWriter() error {
+	w, err := os.OpenFile(s.segmentPath(s.writeSeg), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	s.writer = w
+
+	return nil
+}
+
+func (s *Spool) advanceSegment() error {
+	if s.reader != nil {
+		if err := s.reader.Close(); err != nil {
+			return err
+		}
+		s.reader = nil
+	}
+
+	if err := os.Remove(s.segmentPath(s.readSeg)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove read segment: %w", err)
+	}
+
+	s.readSeg++
+	s.readOffset, s.nextOffset = 0, 0
+
+	return s.saveCursor()
+}
+
+func (s *Spool) readRecord(seg uint64, offset int64) ([]byte, int64, error) {
+	if s.reader == nil || s.reader.Name() != s.segmentPath(seg) {
+		if s.reader != nil {
+			if err := s.reader.Close(); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		r, err := os.Open(s.segmentPath(seg))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		s.reader = r
+	}
+
+	return readRecordAt(s.reader, offset)
+}
+
+func readRecordAt(r io.ReaderAt, offset int64) ([]byte, int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > maxRecordSz {
+		return nil, 0, fmt.Errorf("%w: record size %d", ErrCorrupt, size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := r.ReadAt(payload, offset+headerSize); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	// the size is still known, so the record can be skipped
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, headerSize + int64(size), fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	return payload, headerSize + int64(size), nil
+}
+
+// scanSegment counts records from offset, including the ones with a wrong
+// checksum that Next skips, and returns the end of the last one.
+func (s *Spool) scanSegment(seg uint64, offset int64) (int, int64, error) {
+	f, err := os.OpenFile(s.segmentPath(seg), os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, offset, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.Error("failed to close spool segment", slog.String("error", err.Error()))
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, offset, err
+	}
+
+	count := 0
+	for offset < info.Size() {
+		_, size, err := readRecordAt(f, offset)
+		if err != nil && size == 0 {
+			return count, offset, err
+		}
+		offset += size
+		count++
+	}
+
+	return count, offset, nil
+}
+
+func (s *Spool) segmentLen(seg uint64) int64 {
+	info, err := os.Stat(s.segmentPath(seg))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *Spool) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool dir: %w", err)
+	}
+
+	var segments []uint64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+
+		seg, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+func (s *Spool) segmentPath(seg uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seg, segmentExt))
+}
+
+func (s *Spool) loadCursor() (uint64, int64, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, cursorFile))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read spool cursor: %w", err)
+	}
+
+	var seg uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seg, &offset); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse spool cursor: %w", err)
+	}
+
+	return seg, offset, nil
+}
+
+func (s *Spool) saveCursor() error {
+	path := filepath.Join(s.dir, cursorFile)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d", s.readSeg, s.readOffset)), 0644); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save spool cursor: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func record(i int) Record {
+	return Record{
+		Key:       []byte(strconv.Itoa(i)),
+		Value:     []byte(`{"title":"book ` + strconv.Itoa(i) + `"}`),
+		Headers:   []Header{{Key: "content-type", Value: []byte("application/json")}},
+		Timestamp: time.Unix(int64(i), 0),
+	}
+}
+
+func mustOpen(t *testing.T, dir string) *Spool {
+	t.Helper()
+
+	s, err := Open(dir, 128)
+	if err != nil {
+		t.Fatalf("failed to open spool: %s", err)
+	}
+	return s
+}
+
+func mustNext(t *testing.T, s *Spool, expect int) {
+	t.Helper()
+
+	r, err := s.Next()
+	if err != nil {
+		t.Fatalf("failed to read record %d: %s", expect, err)
+	}
+	if string(r.Key) != strconv.Itoa(expect) {
+		t.Fatalf("expect record %d, but got %s", expect, r.Key)
+	}
+	if err := s.Ack(); err != nil {
+		t.Fatalf("failed to ack record %d: %s", expect, err)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+
+	s := mustOpen(t, dir)
+	if _, err := s.Next(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expect empty spool, but got %v", err)
+	}
+
+	for i := range 10 {
+		if err := s.Append(record(i)); err != nil {
+			t.Fatalf("failed to append record: %s", err)
+		}
+	}
+	if s.Depth() != 10 {
+		t.Fatalf("expect depth 10, but got %d", s.Depth())
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if len(segments) < 2 {
+		t.Fatalf("expect segments to rotate, but got %d segments", len(segments))
+	}
+
+	for i := range 4 {
+		mustNext(t, s, i)
+	}
+
+	// unacknowledged record is returned again after restart
+	if _, err := s.Next(); err != nil {
+		t.Fatalf("failed to read record: %s", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close spool: %s", err)
+	}
+
+	s = mustOpen(t, dir)
+	if s.Depth() != 6 {
+		t.Fatalf("expect depth 6 after reopen, but got %d", s.Depth())
+	}
+	for i := 4; i < 10; i++ {
+		mustNext(t, s, i)
+	}
+	if _, err := s.Next(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expect empty spool, but got %v", err)
+	}
+
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if len(segments) != 1 {
+		t.Errorf("expect drained segments to be removed, but got %d segments", len(segments))
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close spool: %s", err)
+	}
+}
+
+func TestSpoolTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	s := mustOpen(t, dir)
+	if err := s.Append(record(0)); err != nil {
+		t.Fatalf("failed to append record: %s", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close spool: %s", err)
+	}
+
+	f, err := os.OpenFile(s.segmentPath(s.writeSeg), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %s", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 42, 1, 2}); err != nil {
+		t.Fatalf("failed to write torn record: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close segment: %s", err)
+	}
+
+	s = mustOpen(t, dir)
+	if s.Depth() != 1 {
+		t.Fatalf("expect depth 1, but got %d", s.Depth())
+	}
+	if err := s.Append(record(1)); err != nil {
+		t.Fatalf("failed to append record: %s", err)
+	}
+	mustNext(t, s, 0)
+	mustNext(t, s, 1)
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close spool: %s", err)
+	}
+}
+
+// corrupt overwrites the segment at offset.
+func corrupt(t *testing.T, path string, offset int64, data []byte) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %s", err)
+	}
+	if _, err := f.WriteAt(data, offset); err != nil {
+		t.Fatalf("failed to corrupt segment: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close segment: %s", err)
+	}
+}
+
+func TestSpoolCorruptRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		reopen bool
+	}{
+		{name: "while writing"},
+		{name: "after reopen", reopen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			s, err := Open(dir, 1<<20)
+			if err != nil {
+				t.Fatalf("failed to open spool: %s", err)
+			}
+			var offsets []int64
+			for i := range 3 {
+				offsets = append(offsets, s.writeOffset)
+				if err := s.Append(record(i)); err != nil {
+					t.Fatalf("failed to append record: %s", err)
+				}
+			}
+			// the checksum of the second record no longer matches
+			corrupt(t, s.segmentPath(s.writeSeg), offsets[1]+headerSize, []byte("!"))
+
+			if tt.reopen {
+				if err := s.Close(); err != nil {
+					t.Fatalf("failed to close spool: %s", err)
+				}
+				if s, err = Open(dir, 1<<20); err != nil {
+					t.Fatalf("failed to open spool: %s", err)
+				}
+			}
+			if s.Depth() != 3 {
+				t.Fatalf("expect depth 3, but got %d", s.Depth())
+			}
+
+			mustNext(t, s, 0)
+			if _, err := s.Next(); !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("expect corrupted record, but got %v", err)
+			}
+			if err := s.Ack(); err != nil {
+				t.Fatalf("failed to skip corrupted record: %s", err)
+			}
+			mustNext(t, s, 2)
+			if s.Depth() != 0 {
+				t.Fatalf("expect depth 0, but got %d", s.Depth())
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("failed to close spool: %s", err)
+			}
+		})
+	}
+}
+
+func TestSpoolCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("failed to open spool: %s", err)
+	}
+	for i := range 2 {
+		if err := s.Append(record(i)); err != nil {
+			t.Fatalf("failed to append record: %s", err)
+		}
+	}
+	// a broken length loses the rest of the segment
+	corrupt(t, s.segmentPath(s.writeSeg), 0, []byte{0xff, 0xff, 0xff, 0xff})
+
+	if _, err := s.Next(); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expect corrupted segment, but got %v", err)
+	}
+	if err := s.Ack(); err != nil {
+		t.Fatalf("failed to skip corrupted segment: %s", err)
+	}
+	if err := s.Append(record(2)); err != nil {
+		t.Fatalf("failed to append record: %s", err)
+	}
+	mustNext(t, s, 2)
+	if _, err := s.Next(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expect empty spool, but got %v", err)
+	}
+	if s.Depth() != 0 {
+		t.Fatalf("expect depth 0 once drained, but got %d", s.Depth())
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close spool: %s", err)
+	}
+}
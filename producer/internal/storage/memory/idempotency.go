@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"producer/internal/storage"
+	"sync"
+	"time"
+)
+
+type idempotencyEntry struct {
+	record    storage.IdempotencyRecord
+	expiresAt time.Time
+}
+
+type IdempotencyStorage struct {
+	mu        sync.Mutex
+	records   map[string]idempotencyEntry
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+func NewIdempotencyStorage(ttl time.Duration) *IdempotencyStorage {
+	return &IdempotencyStorage{
+		records:   make(map[string]idempotencyEntry),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *IdempotencyStorage) Reserve(key string, fingerprint string) (storage.IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, ok := s.records[key]; ok && now.Before(entry.expiresAt) {
+		return entry.record, false
+	}
+
+	record := storage.IdempotencyRecord{Fingerprint: fingerprint}
+	s.records[key] = idempotencyEntry{
+		record:    record,
+		expiresAt: now.Add(s.ttl),
+	}
+	return record, true
+}
+
+func (s *IdempotencyStorage) Complete(key string, record storage.IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.Completed = true
+	s.records[key] = idempotencyEntry{
+		record:    record,
+		expiresAt: time.Now().Add(s.ttl),
+	}
+}
+
+func (s *IdempotencyStorage) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+func (s *IdempotencyStorage) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+
+	for key, entry := range s.records {
+		if now.After(entry.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"producer/internal/config"
+	"producer/internal/storage"
+	"sync"
+	"time"
+)
+
+const (
+	QuotaBooks = "books"
+	QuotaBytes = "bytes"
+
+	QuotaHourly = "hourly"
+	QuotaDaily  = "daily"
+)
+
+// usage counts books and bytes in a fixed window, windows are aligned to
+// UTC hours and days.
+type usage struct {
+	start time.Time
+	books int64
+	bytes int64
+}
+
+func (u *usage) exceeded(window string, books, bytes, booksLimit, bytesLimit int64, resetAt time.Time) error {
+	if booksLimit > 0 && u.books+books > booksLimit {
+		return &storage.QuotaExceededError{Resource: QuotaBooks, Window: window, Limit: booksLimit, ResetAt: resetAt}
+	}
+	if bytesLimit > 0 && u.bytes+bytes > bytesLimit {
+		return &storage.QuotaExceededError{Resource: QuotaBytes, Window: window, Limit: bytesLimit, ResetAt: resetAt}
+	}
+	return nil
+}
+
+func (u *usage) refund(books, bytes int64) {
+	u.books = max(u.books-books, 0)
+	u.bytes = max(u.bytes-bytes, 0)
+}
+
+type quotaEntry struct {
+	hourly usage
+	daily  usage
+}
+
+type QuotaStorage struct {
+	mu        sync.Mutex
+	entries   map[string]*quotaEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewQuotaStorage() *QuotaStorage {
+	return &QuotaStorage{
+		entries:   make(map[string]*quotaEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Consume accounts books and bytes for the key. Nothing is accounted when
+// any of the limits would be exceeded.
+func (s *QuotaStorage) Consume(key string, quota config.Quota, books int64, bytes int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &quotaEntry{}
+		s.entries[key] = entry
+	}
+
+	hourStart := now.Truncate(time.Hour)
+	if !entry.hourly.start.Equal(hourStart) {
+		entry.hourly = usage{start: hourStart}
+	}
+	dayStart := startOfDay(now)
+	if !entry.daily.start.Equal(dayStart) {
+		entry.daily = usage{start: dayStart}
+	}
+
+	// daily limits go first: when both are exceeded, only the daily reset helps
+	err := entry.daily.exceeded(QuotaDaily, books, bytes, quota.DailyBooks, quota.DailyBytes, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	err = entry.hourly.exceeded(QuotaHourly, books, bytes, quota.HourlyBooks, quota.HourlyBytes, hourStart.Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	entry.hourly.books += books
+	entry.hourly.bytes += bytes
+	entry.daily.books += books
+	entry.daily.bytes += bytes
+
+	return nil
+}
+
+// Refund gives back books and bytes consumed at consumedAt. Windows started
+// since then are left as is.
+func (s *QuotaStorage) Refund(key string, books int64, bytes int64, consumedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	consumedAt = consumedAt.UTC()
+	if entry.hourly.start.Equal(consumedAt.Truncate(time.Hour)) {
+		entry.hourly.refund(books, bytes)
+	}
+	if entry.daily.start.Equal(startOfDay(consumedAt)) {
+		entry.daily.refund(books, bytes)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// sweep drops keys without usage in the current day.
+func (s *QuotaStorage) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Hour {
+		return
+	}
+
+	dayStart := startOfDay(now)
+	for key, entry := range s.entries {
+		if entry.daily.start.Before(dayStart) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"errors"
+	"producer/internal/config"
+	"producer/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestQuotaStorage(t *testing.T) {
+	now := time.Date(2025, 11, 3, 10, 30, 0, 0, time.UTC)
+	quotas := NewQuotaStorage()
+	quotas.now = func() time.Time { return now }
+
+	quota := config.Quota{HourlyBooks: 2, DailyBooks: 3, HourlyBytes: 100}
+
+	for i := 0; i < 2; i++ {
+		if err := quotas.Consume("key", quota, 1, 10); err != nil {
+			t.Fatalf("expect book %d to fit quota, but got %s", i, err)
+		}
+	}
+
+	var exceeded *storage.QuotaExceededError
+	err := quotas.Consume("key", quota, 1, 10)
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("expect quota error, but got %v", err)
+	}
+	if exceeded.Resource != QuotaBooks || exceeded.Window != QuotaHourly || !exceeded.ResetAt.Equal(now.Truncate(time.Hour).Add(time.Hour)) {
+		t.Errorf("unexpected quota error: %+v", exceeded)
+	}
+
+	if err := quotas.Consume("other", quota, 1, 10); err != nil {
+		t.Errorf("expect keys to have separate quotas, but got %s", err)
+	}
+
+	now = now.Add(time.Hour)
+	err = quotas.Consume("key", quota, 1, 200)
+	if !errors.As(err, &exceeded) || exceeded.Resource != QuotaBytes {
+		t.Fatalf("expect bytes quota error, but got %v", err)
+	}
+	if err := quotas.Consume("key", quota, 1, 10); err != nil {
+		t.Fatalf("expect hourly quota to reset, but got %s", err)
+	}
+
+	err = quotas.Consume("key", quota, 1, 10)
+	if !errors.As(err, &exceeded) || exceeded.Window != QuotaDaily || !exceeded.ResetAt.Equal(time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expect daily quota error, but got %v", err)
+	}
+}
+
+func TestQuotaRefund(t *testing.T) {
+	now := time.Date(2025, 11, 3, 10, 30, 0, 0, time.UTC)
+	quotas := NewQuotaStorage()
+	quotas.now = func() time.Time { return now }
+
+	quota := config.Quota{HourlyBooks: 1, DailyBooks: 2}
+
+	if err := quotas.Consume("key", quota, 1, 10); err != nil {
+		t.Fatalf("expect book to fit quota, but got %s", err)
+	}
+	quotas.Refund("key", 1, 10, now)
+	if err := quotas.Consume("key", quota, 1, 10); err != nil {
+		t.Fatalf("expect refunded book not to count, but got %s", err)
+	}
+
+	// the hour the book was consumed in is over, only the daily usage is refunded
+	consumedAt := now
+	now = now.Add(time.Hour)
+	if err := quotas.Consume("key", quota, 1, 10); err != nil {
+		t.Fatalf("expect hourly quota to reset, but got %s", err)
+	}
+	quotas.Refund("key", 1, 10, consumedAt)
+
+	var exceeded *storage.QuotaExceededError
+	err := quotas.Consume("key", quota, 1, 10)
+	if !errors.As(err, &exceeded) || exceeded.Window != QuotaHourly {
+		t.Fatalf("expect hourly quota error, but got %v", err)
+	}
+
+	quotas.Refund("missing", 1, 10, now)
+}
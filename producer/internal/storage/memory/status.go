@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"producer/internal/entity"
+	"producer/internal/storage"
+	"sync"
+	"time"
+)
+
+// statusOrder protects against out-of-order updates: the delivery report and
+// the consumer's status events travel different paths, so a late "delivered"
+// must not overwrite "saved". "accepted" starts a new operation on the book
+// (an update or a delete) and always replaces the previous status.
+var statusOrder = map[entity.Status]int{
+	entity.StatusAccepted:   0,
+	entity.StatusDelivered:  1,
+	entity.StatusProcessing: 2,
+	entity.StatusSaved:      3,
+	entity.StatusDeleted:    3,
+	entity.StatusFailed:     3,
+}
+
+type statusEntry struct {
+	status    entity.BookStatus
+	expiresAt time.Time
+}
+
+type StatusStorage struct {
+	mu        sync.Mutex
+	statuses  map[string]statusEntry
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewStatusStorage(ttl time.Duration) *StatusStorage {
+	return &StatusStorage{
+		statuses:  make(map[string]statusEntry),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *StatusStorage) SetStatus(status entity.BookStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if current, ok := s.statuses[status.Id]; ok && now.Before(current.expiresAt) && status.Status != entity.StatusAccepted {
+		if statusOrder[status.Status] < statusOrder[current.status.Status] {
+			return
+		}
+	}
+
+	s.statuses[status.Id] = statusEntry{
+		status:    status,
+		expiresAt: now.Add(s.ttl),
+	}
+}
+
+func (s *StatusStorage) GetStatus(id string) (entity.BookStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.statuses[id]
+	if !ok || s.now().After(entry.expiresAt) {
+		return entity.BookStatus{}, storage.ErrNotFound
+	}
+
+	return entry.status, nil
+}
+
+func (s *StatusStorage) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+
+	for id, entry := range s.statuses {
+		if now.After(entry.expiresAt) {
+			delete(s.statuses, id)
+		}
+	}
+	s.lastSweep = now
+}
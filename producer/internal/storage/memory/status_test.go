@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"errors"
+	"producer/internal/entity"
+	"producer/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestStatusStorage(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []entity.Status
+		expectStatus entity.Status
+	}{
+		{
+			name:         "in order",
+			statuses:     []entity.Status{entity.StatusAccepted, entity.StatusDelivered, entity.StatusProcessing, entity.StatusSaved},
+			expectStatus: entity.StatusSaved,
+		},
+		{
+			name:         "late delivery report",
+			statuses:     []entity.Status{entity.StatusAccepted, entity.StatusProcessing, entity.StatusSaved, entity.StatusDelivered},
+			expectStatus: entity.StatusSaved,
+		},
+		{
+			name:         "late processing after failure",
+			statuses:     []entity.Status{entity.StatusAccepted, entity.StatusFailed, entity.StatusProcessing},
+			expectStatus: entity.StatusFailed,
+		},
+		{
+			name:         "final status replaces final status",
+			statuses:     []entity.Status{entity.StatusSaved, entity.StatusDeleted},
+			expectStatus: entity.StatusDeleted,
+		},
+		{
+			name:         "accepted starts a new operation",
+			statuses:     []entity.Status{entity.StatusAccepted, entity.StatusSaved, entity.StatusAccepted},
+			expectStatus: entity.StatusAccepted,
+		},
+		{
+			name:         "new operation goes on after accepted",
+			statuses:     []entity.Status{entity.StatusSaved, entity.StatusAccepted, entity.StatusDelivered},
+			expectStatus: entity.StatusDelivered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := NewStatusStorage(time.Hour)
+			for _, status := range tt.statuses {
+				statuses.SetStatus(entity.BookStatus{Id: "book", Status: status})
+			}
+
+			status, err := statuses.GetStatus("book")
+			if err != nil {
+				t.Fatalf("failed to get status: %s", err)
+			}
+			if status.Status != tt.expectStatus {
+				t.Errorf("expect status %s, but got %s", tt.expectStatus, status.Status)
+			}
+		})
+	}
+}
+
+func TestStatusStorageExpiry(t *testing.T) {
+	now := time.Date(2025, 11, 3, 10, 30, 0, 0, time.UTC)
+	statuses := NewStatusStorage(time.Hour)
+	statuses.now = func() time.Time { return now }
+
+	statuses.SetStatus(entity.BookStatus{Id: "book", Status: entity.StatusSaved})
+
+	now = now.Add(2 * time.Hour)
+	if _, err := statuses.GetStatus("book"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expect expired status to be not found, but got %v", err)
+	}
+
+	// an expired status no longer holds back older ones
+	statuses.SetStatus(entity.BookStatus{Id: "book", Status: entity.StatusDelivered})
+	status, err := statuses.GetStatus("book")
+	if err != nil || status.Status != entity.StatusDelivered {
+		t.Errorf("expect status %s, but got %+v, %v", entity.StatusDelivered, status, err)
+	}
+	if _, err := statuses.GetStatus("other"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expect unknown book to be not found, but got %v", err)
+	}
+}
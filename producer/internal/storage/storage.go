@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// QuotaExceededError reports which quota is exhausted and when it resets.
+type QuotaExceededError struct {
+	Resource string
+	Window   string
+	Limit    int64
+	ResetAt  time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s %s quota of %d is exceeded", e.Window, e.Resource, e.Limit)
+}
+
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v3.21.12
+// source: book/book.proto
+
+package bookv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	EventType_EVENT_TYPE_CREATED     EventType = 1
+	EventType_EVENT_TYPE_UPDATED     EventType = 2
+	EventType_EVENT_TYPE_DELETED     EventType = 3
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_CREATED",
+		2: "EVENT_TYPE_UPDATED",
+		3: "EVENT_TYPE_DELETED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"EVENT_TYPE_CREATED":     1,
+		"EVENT_TYPE_UPDATED":     2,
+		"EVENT_TYPE_DELETED":     3,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_book_book_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_book_book_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_book_book_proto_rawDescGZIP(), []int{0}
+}
+
+// BookEvent is the payload of messages in the books topic. Messages carry
+// content-type and schema-version headers; bump the schema version on any
+// change that old consumers can not read.
+//
+// Schema versions:
+//
+//	1 - create events only, type and update_mask are not set
+//	2 - adds type and update_mask
+type BookEvent struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Authors []string               `protobuf:"bytes,3,rep,name=authors,proto3" json:"authors,omitempty"`
+	// empty when the text is kept in the blob store, see text_ref
+	Text    string    `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	TextRef *TextRef  `protobuf:"bytes,5,opt,name=text_ref,json=textRef,proto3" json:"text_ref,omitempty"`
+	Type    EventType `protobuf:"varint,6,opt,name=type,proto3,enum=book.EventType" json:"type,omitempty"`
+	// fields changed by a partial update ("title", "authors", "text");
+	// an update without fields replaces the whole book
+	UpdateMask    []string `protobuf:"bytes,7,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookEvent) Reset() {
+	*x = BookEvent{}
+	mi := &file_book_book_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookEvent) ProtoMessage() {}
+
+func (x *BookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_book_book_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookEvent.ProtoReflect.Descriptor instead.
+func (*BookEvent) Descriptor() ([]byte, []int) {
+	return file_book_book_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BookEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BookEvent) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BookEvent) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *BookEvent) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *BookEvent) GetTextRef() *TextRef {
+	if x != nil {
+		return x.TextRef
+	}
+	return nil
+}
+
+func (x *BookEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *BookEvent) GetUpdateMask() []string {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type TextRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextRef) Reset() {
+	*x = TextRef{}
+	mi := &file_book_book_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextRef) ProtoMessage() {}
+
+func (x *TextRef) ProtoReflect() protoreflect.Message {
+	mi := &file_book_book_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextRef.ProtoReflect.Descriptor instead.
+func (*TextRef) Descriptor() ([]byte, []int) {
+	return file_book_book_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TextRef) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TextRef) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *TextRef) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+var File_book_book_proto protoreflect.FileDescriptor
+
+const file_book_book_proto_rawDesc = "" +
+	"\n" +
+	"\x0fbook/book.proto\x12\x04book\"\xcf\x01\n" +
+	"\tBookEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\aauthors\x18\x03 \x03(\tR\aauthors\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12(\n" +
+	"\btext_ref\x18\x05 \x01(\v2\r.book.TextRefR\atextRef\x12#\n" +
+	"\x04type\x18\x06 \x01(\x0e2\x0f.book.EventTypeR\x04type\x12\x1f\n" +
+	"\vupdate_mask\x18\a \x03(\tR\n" +
+	"updateMask\"G\n" +
+	"\aTextRef\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size*o\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EVENT_TYPE_CREATED\x10\x01\x12\x16\n" +
+	"\x12EVENT_TYPE_UPDATED\x10\x02\x12\x16\n" +
+	"\x12EVENT_TYPE_DELETED\x10\x03B\x10Z\x0ebook.v1;bookv1b\x06proto3"
+
+var (
+	file_book_book_proto_rawDescOnce sync.Once
+	file_book_book_proto_rawDescData []byte
+)
+
+func file_book_book_proto_rawDescGZIP() []byte {
+	file_book_book_proto_rawDescOnce.Do(func() {
+		file_book_book_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_book_book_proto_rawDesc), len(file_book_book_proto_rawDesc)))
+	})
+	return file_book_book_proto_rawDescData
+}
+
+var file_book_book_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_book_book_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_book_book_proto_goTypes = []any{
+	(EventType)(0),    // 0: book.EventType
+	(*BookEvent)(nil), // 1: book.BookEvent
+	(*TextRef)(nil),   // 2: book.TextRef
+}
+var file_book_book_proto_depIdxs = []int32{
+	2, // 0: book.BookEvent.text_ref:type_name -> book.TextRef
+	0, // 1: book.BookEvent.type:type_name -> book.EventType
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_book_book_proto_init() }
+func file_book_book_proto_init() {
+	if File_book_book_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_book_book_proto_rawDesc), len(file_book_book_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_book_book_proto_goTypes,
+		DependencyIndexes: file_book_book_proto_depIdxs,
+		EnumInfos:         file_book_book_proto_enumTypes,
+		MessageInfos:      file_book_book_proto_msgTypes,
+	}.Build()
+	File_book_book_proto = out.File
+	file_book_book_proto_goTypes = nil
+	file_book_book_proto_depIdxs = nil
+}
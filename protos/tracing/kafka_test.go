@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestHeaderCarrier(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	headers := []kafka.Header{
+		{Key: "content-type", Value: []byte("application/x-protobuf")},
+		{Key: "traceparent", Value: []byte("stale")},
+	}
+	propagator := propagation.TraceContext{}
+	propagator.Inject(ctx, NewHeaderCarrier(&headers))
+
+	if len(headers) != 2 {
+		t.Fatalf("expect traceparent to replace the existing header, got %d headers", len(headers))
+	}
+	if headers[0].Key != "content-type" {
+		t.Errorf("expect other headers to be kept, got %s", headers[0].Key)
+	}
+
+	extracted := trace.SpanContextFromContext(propagator.Extract(context.Background(), NewHeaderCarrier(&headers)))
+	if extracted.TraceID() != spanContext.TraceID() || extracted.SpanID() != spanContext.SpanID() {
+		t.Errorf("expect span context %s/%s, got %s/%s",
+			spanContext.TraceID(), spanContext.SpanID(), extracted.TraceID(), extracted.SpanID())
+	}
+	if !extracted.IsRemote() {
+		t.Errorf("expect extracted span context to be remote")
+	}
+}